@@ -5,6 +5,7 @@ import (
 	"os"
 	"webplus-openapi/pkg/db"
 	"webplus-openapi/pkg/nsc"
+	"webplus-openapi/pkg/search"
 	"webplus-openapi/pkg/server"
 	"webplus-openapi/pkg/signals"
 	"webplus-openapi/pkg/util"
@@ -65,8 +66,16 @@ func startServer(cfg *server.Config, ctx context.Context) error {
 		zap.S().Warn("未配置 db_storage，将无法从 article 存储库读取数据")
 	}
 
+	//初始化全文检索索引
+	if err := search.Init(cfg.SearchIndex); err != nil {
+		zap.S().Fatalf("初始化全文检索索引失败。%s", err.Error())
+	}
+
 	//启动web服务
-	webServer := server.NewServer(cfg)
+	webServer, err := server.NewServer(cfg)
+	if err != nil {
+		zap.S().Fatalf("启动web服务失败。%s", err.Error())
+	}
 
 	//初始化业务
 	if err := server.Init(cfg); err != nil {
@@ -77,6 +86,7 @@ func startServer(cfg *server.Config, ctx context.Context) error {
 	g.Go(func() error {
 		return webServer.Run()
 	})
+	g.Go(func() error { return webServer.RunWorkers(c) })
 	//启动nats监听
 	g.Go(func() error { return server.GetInstance().Serve(cfg, ctx) })
 	g.Go(func() error {
@@ -84,6 +94,10 @@ func startServer(cfg *server.Config, ctx context.Context) error {
 		_ = webServer.GracefulShutdown(c)
 		return c.Err()
 	})
-	return g.Wait()
+	err = g.Wait()
+	if idx := search.GetIndex(); idx != nil {
+		_ = idx.Close()
+	}
+	return err
 
 }
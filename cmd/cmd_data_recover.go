@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/metrics"
 	"webplus-openapi/pkg/recover"
+	"webplus-openapi/pkg/search"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -16,6 +19,8 @@ func NewRecoverCommand() *cobra.Command {
 		batchSize      int    // 批次大小
 		concurrency    int    // 并发数
 		workerPoolSize int    // Worker池大小
+		rebuildIndex   bool   // 仅重建全文检索索引
+		rebuildFields  bool   // 仅重建扩展字段索引
 	)
 	var configFilePath string
 	cmd := &cobra.Command{
@@ -40,6 +45,12 @@ func NewRecoverCommand() *cobra.Command {
 				WorkerPoolSize: workerPoolSize,
 			}
 
+			if rebuildIndex {
+				return runSearchIndexRebuild(cfg, batchSize)
+			}
+			if rebuildFields {
+				return runFieldIndexRebuild(cfg, batchSize)
+			}
 			return runHistoryDataRecover(cfg, params)
 		},
 	}
@@ -49,6 +60,8 @@ func NewRecoverCommand() *cobra.Command {
 	cmd.Flags().IntVar(&batchSize, "batchSize", 500, "批次大小")
 	cmd.Flags().IntVar(&concurrency, "concurrency", 0, "并发数 (0表示使用CPU核心数)")
 	cmd.Flags().IntVar(&workerPoolSize, "workerPoolSize", 0, "Worker池大小 (0表示使用并发数的2倍)")
+	cmd.Flags().BoolVar(&rebuildIndex, "rebuildIndex", false, "仅根据目标库重建全文检索索引，不恢复文章")
+	cmd.Flags().BoolVar(&rebuildFields, "rebuildFieldIndex", false, "仅根据目标库重建扩展字段索引（article_field_index），不恢复文章")
 
 	return cmd
 }
@@ -68,6 +81,8 @@ func runHistoryDataRecover(cfg *recover.Config, params recover.Params) error {
 	}
 	zap.S().Info("目标库初始化成功")
 
+	// 恢复不打开全文检索索引（索引目录由 api 服务占用），api 服务根据变更日志同步恢复的文章
+
 	// 2. 获取数据库连接实例
 	sourceDB := db.GetSourceDB()
 	if sourceDB == nil {
@@ -96,7 +111,9 @@ func runHistoryDataRecover(cfg *recover.Config, params recover.Params) error {
 	zap.S().Infof("恢复参数: SiteID=%s,  BatchSize=%d, Concurrency=%d, WorkerPoolSize=%d",
 		params.SiteID, params.BatchSize, params.Concurrency, params.WorkerPoolSize)
 
-	// 7. 执行历史数据恢复
+	// 7. 执行历史数据恢复，进度通过指标监听或推送，结束后再推送一次
+	stopMetrics := metrics.Start(cfg.Metrics, "webplus-openapi-recover")
+	defer stopMetrics()
 	if err := recoverService.RecoverHistoryData(params); err != nil {
 		zap.S().Errorf("历史数据恢复失败: %s", err.Error())
 		return fmt.Errorf("历史数据恢复失败: %w", err)
@@ -105,3 +122,46 @@ func runHistoryDataRecover(cfg *recover.Config, params recover.Params) error {
 	zap.S().Info("历史数据恢复任务成功完成")
 	return nil
 }
+
+// runSearchIndexRebuild 根据目标库中已有的文章重建全文检索索引，需要先停止 api 服务释放索引目录
+func runSearchIndexRebuild(cfg *recover.Config, batchSize int) error {
+	if cfg.SearchIndex == nil || !cfg.SearchIndex.Enabled {
+		return errors.New("未启用全文检索索引（searchIndex.enabled）")
+	}
+	if err := db.InitTargetDB(cfg.TargetDB); err != nil {
+		zap.S().Errorf("目标库初始化失败: %s", err.Error())
+		return fmt.Errorf("目标库初始化失败: %w", err)
+	}
+	if err := search.Init(cfg.SearchIndex); err != nil {
+		zap.S().Errorf("全文检索索引初始化失败: %s", err.Error())
+		return fmt.Errorf("全文检索索引初始化失败: %w", err)
+	}
+	idx := search.GetIndex()
+	defer func() { _ = idx.Close() }()
+
+	startTime := time.Now()
+	total, err := idx.Rebuild(db.GetTargetDB(), batchSize)
+	if err != nil {
+		zap.S().Errorf("重建全文检索索引失败: %s", err.Error())
+		return fmt.Errorf("重建全文检索索引失败: %w", err)
+	}
+	zap.S().Infof("全文检索索引重建完成，共 %d 篇文章，耗时：%v", total, time.Since(startTime))
+	return nil
+}
+
+// runFieldIndexRebuild 根据目标库中已有文章的扩展字段重建 article_field_index
+func runFieldIndexRebuild(cfg *recover.Config, batchSize int) error {
+	if err := db.InitTargetDB(cfg.TargetDB); err != nil {
+		zap.S().Errorf("目标库初始化失败: %s", err.Error())
+		return fmt.Errorf("目标库初始化失败: %w", err)
+	}
+
+	startTime := time.Now()
+	total, err := db.RebuildArticleFieldIndex(db.GetTargetDB(), batchSize)
+	if err != nil {
+		zap.S().Errorf("重建扩展字段索引失败: %s", err.Error())
+		return fmt.Errorf("重建扩展字段索引失败: %w", err)
+	}
+	zap.S().Infof("扩展字段索引重建完成，共 %d 篇文章，耗时：%v", total, time.Since(startTime))
+	return nil
+}
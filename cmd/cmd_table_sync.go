@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"syscall"
 	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/metrics"
 	"webplus-openapi/pkg/sync"
 	"webplus-openapi/pkg/util"
 
@@ -94,6 +95,10 @@ func runSyncServer(cfg *sync.Config) error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
+	// 指标监听或推送，退出前再推送一次
+	stopMetrics := metrics.Start(cfg.Metrics, "webplus-openapi-sync")
+	defer stopMetrics()
+
 	// 如果配置了启动时立即执行
 	if cfg.Schedule != nil && cfg.Schedule.RunOnStart {
 		zap.S().Info("启动时立即执行一次同步...")
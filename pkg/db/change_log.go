@@ -0,0 +1,67 @@
+package db
+
+import (
+	"fmt"
+	"webplus-openapi/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecordArticleChange 在事务中写入一条文章变更日志。seq 从 article_change_seq 加锁取号，锁在事务结束时释放，
+// 并发的事务在这里排队，应在事务的其他写操作之后、提交之前调用，以缩短持锁时间
+func RecordArticleChange(tx *gorm.DB, change *models.ArticleChangeLog) error {
+	var counter models.ArticleChangeSeq
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&counter, 1).Error; err != nil {
+		return fmt.Errorf("获取变更日志 seq 失败: %v", err)
+	}
+	counter.Seq++
+	if err := tx.Model(&counter).Update("seq", counter.Seq).Error; err != nil {
+		return fmt.Errorf("更新变更日志 seq 失败: %v", err)
+	}
+	change.Seq = counter.Seq
+	if err := tx.Create(change).Error; err != nil {
+		return fmt.Errorf("写入 article_change_log 失败: %v", err)
+	}
+	return nil
+}
+
+// NewArticleDeleteChange 在删除文章数据之前调用，查询文章删除前所在的站点和栏目作为删除的墓碑；
+// 返回的变更日志尚未写入，删除完成后再调用 RecordArticleChange
+func NewArticleDeleteChange(tx *gorm.DB, articleId int64, source string) (*models.ArticleChangeLog, error) {
+	var siteIds []string
+	if err := tx.Table(models.TableNameArticleStatic).
+		Where("articleId = ?", articleId).
+		Pluck("createSiteId", &siteIds).Error; err != nil {
+		return nil, fmt.Errorf("查询文章站点失败: %v", err)
+	}
+	var columnIds []int64
+	if err := tx.Table(models.TableNameArticleDynamic).
+		Where("articleId = ?", articleId).
+		Order("columnId").
+		Pluck("columnId", &columnIds).Error; err != nil {
+		return nil, fmt.Errorf("查询文章栏目失败: %v", err)
+	}
+	change := &models.ArticleChangeLog{
+		ArticleId: articleId,
+		Op:        models.ArticleChangeDelete,
+		ColumnIds: models.JoinColumnIds(columnIds),
+		Source:    source,
+	}
+	if len(siteIds) > 0 {
+		change.SiteId = siteIds[0]
+	}
+	return change, nil
+}
+
+// initArticleChangeSeq 创建 seq 计数器行，并保证计数器不小于已有的最大 seq（兼容此前由自增列分配的 seq）
+func initArticleChangeSeq(targetDB *gorm.DB) error {
+	if err := targetDB.Exec("INSERT IGNORE INTO " + models.TableNameArticleChangeSeq + " (id, seq) VALUES (1, 0)").Error; err != nil {
+		return fmt.Errorf("初始化 article_change_seq 失败: %v", err)
+	}
+	var maxSeq int64
+	if err := targetDB.Model(&models.ArticleChangeLog{}).Select("COALESCE(MAX(seq), 0)").Scan(&maxSeq).Error; err != nil {
+		return fmt.Errorf("查询变更日志最大 seq 失败: %v", err)
+	}
+	return targetDB.Model(&models.ArticleChangeSeq{Id: 1}).Where("seq < ?", maxSeq).Update("seq", maxSeq).Error
+}
@@ -65,18 +65,37 @@ func InitTargetDB(cfg *Config) error {
 			},
 			Logger: logger.Default.LogMode(logger.Silent),
 		})
+		if err != nil {
+			return
+		}
 		if cfg != nil && cfg.Debug {
 			gormTargetDB = gormTargetDB.Debug()
 		}
-		err := gormTargetDB.AutoMigrate(&models.ArticleStatic{}, &models.ArticleDynamic{}, &models.ArticleAttachment{}, &models.TColumn{}, &models.TSite{}, &models.TPublishSite{})
+		err = gormTargetDB.AutoMigrate(&models.ArticleStatic{}, &models.ArticleDynamic{}, &models.ArticleAttachment{}, &models.TColumn{}, &models.TSite{}, &models.TPublishSite{}, &models.ApiUsage{}, &models.SyncState{}, &models.ArticleFieldIndex{}, &models.ArticleChangeLog{}, &models.ArticleChangeSeq{}, &models.WebhookSubscription{}, &models.WebhookDelivery{})
 		if err != nil {
 			return
 		}
+		if err = initArticleChangeSeq(gormTargetDB); err != nil {
+			return
+		}
+		backfillLastModifyTime(gormTargetDB)
 		zap.S().Debug("*** targetDB 数据库初始化完成 ***")
 	})
 	return err
 }
 
+// backfillLastModifyTime 早期版本通过 NATS 同步的文章没有记录修改时间，以发布时间补齐，失败只记录日志
+func backfillLastModifyTime(targetDB *gorm.DB) {
+	result := targetDB.Table(models.TableNameArticleStatic).
+		Where("lastModifyTime IS NULL AND publishTime IS NOT NULL").
+		Update("lastModifyTime", gorm.Expr("publishTime"))
+	if result.Error != nil {
+		zap.S().Warnf("补齐 article_static.lastModifyTime 失败: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		zap.S().Infof("已用发布时间补齐 %d 篇文章的修改时间", result.RowsAffected)
+	}
+}
+
 func GetTargetDB() *gorm.DB {
 	return gormTargetDB
 }
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"fmt"
+	"webplus-openapi/pkg/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ReplaceArticleFieldIndex 在事务中重写一篇文章的扩展字段索引
+func ReplaceArticleFieldIndex(tx *gorm.DB, articleId int64, fields models.ArticleFields) error {
+	if err := tx.Where("articleId = ?", articleId).Delete(&models.ArticleFieldIndex{}).Error; err != nil {
+		return fmt.Errorf("清理 article_field_index 失败: %v", err)
+	}
+	rows := models.NewArticleFieldIndexes(articleId, fields)
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return fmt.Errorf("写入 article_field_index 失败: %v", err)
+	}
+	return nil
+}
+
+// RebuildArticleFieldIndex 按 articleId 顺序分批读取 article_static 的扩展字段并重建索引，返回处理的文章数
+func RebuildArticleFieldIndex(targetDB *gorm.DB, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	columns := []string{"articleId"}
+	for i := 1; i <= 50; i++ {
+		columns = append(columns, fmt.Sprintf("field%d", i))
+	}
+	var (
+		lastId int64
+		total  int
+	)
+	for {
+		var rows []struct {
+			ArticleId int64 `gorm:"column:articleId"`
+			models.ArticleFields
+		}
+		if err := targetDB.Table(models.TableNameArticleStatic).
+			Select(columns).
+			Where("articleId > ?", lastId).
+			Order("articleId ASC").
+			Limit(batchSize).
+			Scan(&rows).Error; err != nil {
+			return total, fmt.Errorf("查询文章扩展字段失败: %w", err)
+		}
+		if len(rows) == 0 {
+			return total, nil
+		}
+		err := targetDB.Transaction(func(tx *gorm.DB) error {
+			for _, r := range rows {
+				if err := ReplaceArticleFieldIndex(tx, r.ArticleId, r.ArticleFields); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		total += len(rows)
+		lastId = rows[len(rows)-1].ArticleId
+		zap.S().Infof("已重建 %d 篇文章的扩展字段索引", total)
+	}
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import "time"
+
+// Config Prometheus 指标配置，api 服务、sync、recover 共用
+type Config struct {
+	// Enabled 是否暴露指标；未启用时指标照常统计，只是不监听也不推送
+	Enabled bool `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	// Path 指标路径
+	Path string `json:"path" yaml:"path" mapstructure:"path"`
+	// Listen 单独监听的地址，如 ":9101"；sync、recover 为空时不监听，
+	// api 服务为空时注册在服务端口上，只允许 admin API Key 访问
+	Listen string `json:"listen" yaml:"listen" mapstructure:"listen"`
+	// PushGateway sync、recover 推送的 Pushgateway 地址，如 "http://127.0.0.1:9091"，为空不推送
+	PushGateway string `json:"pushGateway" yaml:"pushGateway" mapstructure:"pushGateway"`
+	// PushInterval 推送间隔（秒），进程退出前会再推送一次
+	PushInterval int `json:"pushInterval" yaml:"pushInterval" mapstructure:"pushInterval"`
+}
+
+func NewDefaultConfig() *Config {
+	return &Config{
+		Enabled:      false,
+		Path:         "/metrics",
+		PushInterval: 15,
+	}
+}
+
+// IsEnabled 配置为空时视为未启用
+func (c *Config) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+// GetPath 指标路径，未配置时为 /metrics
+func (c *Config) GetPath() string {
+	if c == nil || c.Path == "" {
+		return "/metrics"
+	}
+	return c.Path
+}
+
+func (c *Config) pushInterval() time.Duration {
+	if c.PushInterval <= 0 {
+		return 15 * time.Second
+	}
+	return time.Duration(c.PushInterval) * time.Second
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"go.uber.org/zap"
+)
+
+// Handler 输出全部指标的 http.Handler
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// Start 供 sync、recover 使用：按配置单独监听指标地址、定时推送到 Pushgateway，
+// 返回的 stop 在进程退出前调用，会关闭监听并做最后一次推送；未启用时 stop 不做任何事
+func Start(cfg *Config, job string) (stop func()) {
+	if !cfg.IsEnabled() {
+		return func() {}
+	}
+	var srv *http.Server
+	if cfg.Listen != "" {
+		srv = listen(cfg)
+	}
+
+	var pusher *push.Pusher
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	if cfg.PushGateway != "" {
+		instance, _ := os.Hostname()
+		pusher = push.New(cfg.PushGateway, job).Gatherer(registry).Grouping("instance", instance)
+		go func() {
+			defer close(finished)
+			ticker := time.NewTicker(cfg.pushInterval())
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					pushOnce(pusher)
+				}
+			}
+		}()
+	} else {
+		close(finished)
+	}
+
+	return func() {
+		close(done)
+		<-finished
+		if pusher != nil {
+			pushOnce(pusher)
+		}
+		if srv != nil {
+			shutdown(srv)
+		}
+	}
+}
+
+// Serve 供 api 服务使用：在 cfg.Listen 单独监听指标地址，不推送；返回的 stop 关闭监听，未启用或未配置 listen 时不做任何事
+func Serve(cfg *Config) (stop func()) {
+	if !cfg.IsEnabled() || cfg.Listen == "" {
+		return func() {}
+	}
+	srv := listen(cfg)
+	return func() { shutdown(srv) }
+}
+
+// listen 在 cfg.Listen 上启动只提供指标路径的 http 服务
+func listen(cfg *Config) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle(cfg.GetPath(), Handler())
+	srv := &http.Server{Addr: cfg.Listen, Handler: mux}
+	go func() {
+		zap.S().Infof("指标服务启动在 %s%s", cfg.Listen, cfg.GetPath())
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			zap.S().Errorf("指标服务启动失败: %v", err)
+		}
+	}()
+	return srv
+}
+
+func shutdown(srv *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = srv.Shutdown(ctx)
+}
+
+// pushOnce 推送一次，失败只记录日志
+func pushOnce(pusher *push.Pusher) {
+	if err := pusher.Push(); err != nil {
+		zap.S().Warnf("推送指标到 Pushgateway 失败: %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "webplus"
+
+// registry 本进程的全部指标，/metrics 和 Pushgateway 推送使用同一份
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP 请求数，route 为注册的路由模板",
+	}, []string{"method", "route", "code"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP 请求耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	natsProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "nats_messages_processed_total",
+		Help:      "处理成功的 NATS 消息数",
+	}, []string{"operate"})
+	natsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "nats_messages_failed_total",
+		Help:      "处理失败的 NATS 消息数",
+	}, []string{"operate"})
+	natsDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "nats_message_duration_seconds",
+		Help:      "单条 NATS 消息的处理耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operate"})
+	natsLastMessage = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "nats_last_message_timestamp_seconds",
+		Help:      "最后一条 NATS 消息处理完成的时间",
+	})
+	natsPending = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "nats_consumer_pending_messages",
+		Help:      "consumer 尚未投递的消息数",
+	}, []string{"consumer"})
+	natsAckPending = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "nats_consumer_ack_pending_messages",
+		Help:      "consumer 已投递未确认的消息数",
+	}, []string{"consumer"})
+
+	tableSyncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "table_sync_duration_seconds",
+		Help:      "站点表、栏目表单次同步耗时",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	}, []string{"table"})
+	tableSyncRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "table_sync_runs_total",
+		Help:      "表同步次数，result 为 success 或 failed",
+	}, []string{"table", "result"})
+	tableSyncRows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "table_sync_rows_total",
+		Help:      "表同步新增、更新、删除的行数",
+	}, []string{"table", "change"})
+	tableSyncLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "table_sync_last_success_timestamp_seconds",
+		Help:      "最后一次同步成功的时间",
+	}, []string{"table"})
+
+	recoverArticles = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "recover_articles",
+		Help:      "本次恢复需要处理的文章数",
+	})
+	recoverArticlesDone = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "recover_articles_done_total",
+		Help:      "已处理的文章数，status 为 processed、skipped 或 error",
+	}, []string{"status"})
+	recoverBatches = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "recover_batches",
+		Help:      "本次恢复的批次数",
+	})
+	recoverBatchesDone = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "recover_batches_done_total",
+		Help:      "已完成的批次数，result 为 success 或 failed",
+	}, []string{"result"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		natsProcessed, natsFailed, natsDuration, natsLastMessage, natsPending, natsAckPending,
+		tableSyncDuration, tableSyncRuns, tableSyncRows, tableSyncLastSuccess,
+		recoverArticles, recoverArticlesDone, recoverBatches, recoverBatchesDone,
+	)
+}
+
+// ObserveHTTPRequest 记录一次 HTTP 请求
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// ObserveNatsMessage 记录一条 NATS 消息的处理结果，err 不为空时计为失败
+func ObserveNatsMessage(operate string, err error, duration time.Duration) {
+	if err != nil {
+		natsFailed.WithLabelValues(operate).Inc()
+	} else {
+		natsProcessed.WithLabelValues(operate).Inc()
+	}
+	natsDuration.WithLabelValues(operate).Observe(duration.Seconds())
+	natsLastMessage.SetToCurrentTime()
+}
+
+// SetNatsPending 记录 consumer 积压的消息数
+func SetNatsPending(consumer string, pending uint64, ackPending int) {
+	natsPending.WithLabelValues(consumer).Set(float64(pending))
+	natsAckPending.WithLabelValues(consumer).Set(float64(ackPending))
+}
+
+// ObserveTableSync 记录一次表同步，失败时只记录耗时和次数
+func ObserveTableSync(table string, added, updated, deleted int, duration time.Duration, err error) {
+	tableSyncDuration.WithLabelValues(table).Observe(duration.Seconds())
+	if err != nil {
+		tableSyncRuns.WithLabelValues(table, "failed").Inc()
+		return
+	}
+	tableSyncRuns.WithLabelValues(table, "success").Inc()
+	tableSyncRows.WithLabelValues(table, "added").Add(float64(added))
+	tableSyncRows.WithLabelValues(table, "updated").Add(float64(updated))
+	tableSyncRows.WithLabelValues(table, "deleted").Add(float64(deleted))
+	tableSyncLastSuccess.WithLabelValues(table).SetToCurrentTime()
+}
+
+// SetRecoverTotal 记录本次恢复的文章数和批次数
+func SetRecoverTotal(articles, batches int) {
+	recoverArticles.Set(float64(articles))
+	recoverBatches.Set(float64(batches))
+}
+
+// AddRecoverBatch 记录一个批次的处理结果，批次失败时 errors 为整批文章数
+func AddRecoverBatch(processed, skipped, errors int, failed bool) {
+	recoverArticlesDone.WithLabelValues("processed").Add(float64(processed))
+	recoverArticlesDone.WithLabelValues("skipped").Add(float64(skipped))
+	recoverArticlesDone.WithLabelValues("error").Add(float64(errors))
+	if failed {
+		recoverBatchesDone.WithLabelValues("failed").Inc()
+	} else {
+		recoverBatchesDone.WithLabelValues("success").Inc()
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+const TableNameApiUsage = "api_usage"
+
+// ApiUsage 调用方每日请求次数
+type ApiUsage struct {
+	Id         int64  `json:"id" gorm:"primaryKey;autoIncrement"`
+	ClientName string `json:"clientName" gorm:"column:clientName;type:varchar(128);uniqueIndex:uk_client_date"` // 调用方名称，未启用认证时为 anonymous
+	Date       string `json:"date" gorm:"column:date;type:varchar(10);uniqueIndex:uk_client_date"`              // 日期，如 2025-01-01
+	Count      int64  `json:"count" gorm:"column:requestCount"`                                                 // 请求次数
+}
+
+func (*ApiUsage) TableName() string {
+	return TableNameApiUsage
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const TableNameArticleChangeLog = "article_change_log"
+
+// 文章变更类型
+const (
+	ArticleChangeUpsert       = "upsert"        // 文章新增或修改
+	ArticleChangeDelete       = "delete"        // 文章删除（墓碑）
+	ArticleChangeColumnAdd    = "column_add"    // 文章发布到栏目
+	ArticleChangeColumnRemove = "column_remove" // 文章从栏目移除
+)
+
+// 变更来源
+const (
+	ArticleChangeSourceNats    = "nats"
+	ArticleChangeSourceRecover = "recover"
+)
+
+// ArticleChangeLog 文章变更日志，与数据变更在同一事务中写入；seq 由 article_change_seq 分配，下游按 seq 增量拉取
+type ArticleChangeLog struct {
+	Seq       int64     `json:"seq" gorm:"column:seq;primaryKey;autoIncrement:false"`
+	ArticleId int64     `json:"articleId" gorm:"column:articleId;index:idx_change_article"`
+	Op        string    `json:"op" gorm:"column:op;type:varchar(16)"`               // upsert、delete、column_add、column_remove
+	SiteId    string    `json:"siteId" gorm:"column:siteId;type:varchar(32);index"` // 文章所属站点
+	ColumnId  int64     `json:"columnId,omitempty" gorm:"column:columnId"`          // column_add、column_remove 变更的栏目
+	ColumnIds string    `json:"-" gorm:"column:columnIds;type:text"`                // 变更时文章所在的栏目，逗号分隔，用于按栏目限制范围
+	Source    string    `json:"source" gorm:"column:source;type:varchar(16)"`       // nats、recover
+	Created   bool      `json:"created" gorm:"column:created"`                      // upsert 时文章此前不存在
+	CreatedAt time.Time `json:"createdAt" gorm:"column:createdAt;index"`
+}
+
+func (*ArticleChangeLog) TableName() string {
+	return TableNameArticleChangeLog
+}
+
+const TableNameArticleChangeSeq = "article_change_seq"
+
+// ArticleChangeSeq 变更日志的 seq 计数器，只有 id=1 一行。写变更日志时锁住该行取号，锁持有到事务提交，
+// 使 seq 的分配顺序与提交顺序一致：下游读到 seq=N 时，小于 N 的变更都已提交或已回滚，按 seq 增量拉取不会漏掉
+type ArticleChangeSeq struct {
+	Id  int   `gorm:"column:id;primaryKey;autoIncrement:false"`
+	Seq int64 `gorm:"column:seq"` // 最近分配的 seq
+}
+
+func (*ArticleChangeSeq) TableName() string {
+	return TableNameArticleChangeSeq
+}
+
+// JoinColumnIds 将栏目ID拼接为 columnIds 列的格式
+func JoinColumnIds(columnIds []int64) string {
+	parts := make([]string, len(columnIds))
+	for i, id := range columnIds {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
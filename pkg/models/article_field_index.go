@@ -0,0 +1,84 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const TableNameArticleFieldIndex = "article_field_index"
+
+// ArticleFieldIndexMaxLen strValue 保存的最大字符数，超出部分截断
+const ArticleFieldIndexMaxLen = 255
+
+// ArticleFieldIndex 扩展字段 field1-field50 的索引表，每篇文章每个非空字段一行
+// 同一个值同时按字符串、整数、日期解析保存，能解析的类型才有值，查询时按配置的字段类型选择列
+type ArticleFieldIndex struct {
+	ArticleId int64      `json:"articleId" gorm:"column:articleId;primaryKey;autoIncrement:false"`
+	Field     string     `json:"field" gorm:"column:field;type:varchar(16);primaryKey;index:idx_field_str,priority:1;index:idx_field_num,priority:1;index:idx_field_time,priority:1"` // 字段名，如 field12
+	StrValue  string     `json:"strValue" gorm:"column:strValue;type:varchar(255);index:idx_field_str,priority:2"`                                                                    // 原始值
+	NumValue  *int64     `json:"numValue" gorm:"column:numValue;index:idx_field_num,priority:2"`                                                                                      // 按整数解析的值
+	TimeValue *time.Time `json:"timeValue" gorm:"column:timeValue;index:idx_field_time,priority:2"`                                                                                   // 按日期解析的值（北京时间）
+}
+
+func (*ArticleFieldIndex) TableName() string {
+	return TableNameArticleFieldIndex
+}
+
+// articleFieldTimeFormats 扩展字段中常见的日期格式
+var articleFieldTimeFormats = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05Z07:00",
+	"2006/01/02",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006年01月02日",
+	"2006年1月2日",
+	"2006.01.02",
+}
+
+// articleFieldLocation 扩展字段中无时区的时间按北京时间处理，只加载一次；系统缺少时区数据时使用固定的 UTC+8
+var articleFieldLocation = func() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Shanghai"); err == nil {
+		return loc
+	}
+	return time.FixedZone("CST", 8*3600)
+}()
+
+// ParseArticleFieldTime 按扩展字段常见的日期格式解析，无时区的值按北京时间处理；dateOnly 表示只包含日期
+func ParseArticleFieldTime(s string) (t time.Time, dateOnly bool, ok bool) {
+	s = strings.TrimSpace(s)
+	for _, f := range articleFieldTimeFormats {
+		parsed, err := time.ParseInLocation(f, s, articleFieldLocation)
+		if err == nil {
+			return parsed.In(articleFieldLocation), !strings.Contains(f, "15"), true
+		}
+	}
+	return time.Time{}, false, false
+}
+
+// NewArticleFieldIndexes 根据文章的扩展字段生成索引行，空值不建索引
+func NewArticleFieldIndexes(articleId int64, fields ArticleFields) []ArticleFieldIndex {
+	var rows []ArticleFieldIndex
+	for name, value := range fields.ToMap() {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		row := ArticleFieldIndex{ArticleId: articleId, Field: name, StrValue: value}
+		if utf8.RuneCountInString(value) > ArticleFieldIndexMaxLen {
+			row.StrValue = string([]rune(value)[:ArticleFieldIndexMaxLen])
+		}
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			row.NumValue = &n
+		}
+		if t, _, ok := ParseArticleFieldTime(value); ok {
+			row.TimeValue = &t
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+const TableNameSyncState = "sync_state"
+
+// SyncState table sync 每张表最近一次同步的结果，api 服务据此判断是否需要清空响应缓存
+type SyncState struct {
+	Table          string     `json:"table" gorm:"column:tableName;type:varchar(64);primaryKey"` // 同步的表名，如 T_COLUMN
+	LastSyncTime   *time.Time `json:"lastSyncTime" gorm:"column:lastSyncTime"`                   // 最近一次同步完成时间
+	LastChangeTime *time.Time `json:"lastChangeTime" gorm:"column:lastChangeTime"`               // 最近一次有数据变更的同步完成时间
+	Added          int        `json:"added" gorm:"column:added"`                                 // 最近一次同步新增数
+	Updated        int        `json:"updated" gorm:"column:updated"`                             // 最近一次同步更新数
+	Deleted        int        `json:"deleted" gorm:"column:deleted"`                             // 最近一次同步删除数
+}
+
+func (*SyncState) TableName() string {
+	return TableNameSyncState
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+const (
+	TableNameWebhookSubscription = "webhook_subscription"
+	TableNameWebhookDelivery     = "webhook_delivery"
+)
+
+// webhook 投递状态
+const (
+	WebhookDeliveryPending = "pending" // 等待投递或重试
+	WebhookDeliverySuccess = "success" // 对方返回 2xx
+	WebhookDeliveryFailed  = "failed"  // 超过最大重试次数或订阅已停用
+)
+
+// WebhookSubscription webhook 订阅，文章变更提交后按站点、栏目、变更类型匹配并投递
+// SiteIds、ColumnIds 都为空时不限制范围，否则两者取并集；Ops 为空时订阅全部变更类型
+type WebhookSubscription struct {
+	Id        int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Name      string    `json:"name" gorm:"column:name;type:varchar(64)"`
+	Url       string    `json:"url" gorm:"column:url;type:varchar(1024)"`
+	Secret    string    `json:"-" gorm:"column:secret;type:varchar(128)"`             // HMAC-SHA256 签名密钥
+	SiteIds   string    `json:"siteIds" gorm:"column:siteIds;type:varchar(1024)"`     // 逗号分隔
+	ColumnIds string    `json:"columnIds" gorm:"column:columnIds;type:varchar(1024)"` // 逗号分隔
+	Ops       string    `json:"ops" gorm:"column:ops;type:varchar(128)"`              // 逗号分隔：upsert、delete、column_add、column_remove
+	Enabled   bool      `json:"enabled" gorm:"column:enabled"`
+	CreatedAt time.Time `json:"createdAt" gorm:"column:createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"column:updatedAt"`
+}
+
+func (*WebhookSubscription) TableName() string {
+	return TableNameWebhookSubscription
+}
+
+// WebhookDelivery webhook 投递记录，每个订阅每个变更一行，记录重试次数和最近一次结果
+type WebhookDelivery struct {
+	Id             int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	SubscriptionId int64      `json:"subscriptionId" gorm:"column:subscriptionId;index:idx_delivery_sub"`
+	ChangeSeq      int64      `json:"changeSeq" gorm:"column:changeSeq"` // article_change_log.seq
+	ArticleId      int64      `json:"articleId" gorm:"column:articleId"`
+	Op             string     `json:"op" gorm:"column:op;type:varchar(16)"`
+	Payload        string     `json:"payload" gorm:"column:payload;type:text"` // 投递的请求体
+	Status         string     `json:"status" gorm:"column:status;type:varchar(16);index:idx_delivery_due,priority:1"`
+	Attempts       int        `json:"attempts" gorm:"column:attempts"`
+	ResponseCode   int        `json:"responseCode" gorm:"column:responseCode"`
+	LastError      string     `json:"lastError" gorm:"column:lastError;type:varchar(1024)"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt" gorm:"column:nextAttemptAt;index:idx_delivery_due,priority:2"`
+	DeliveredAt    *time.Time `json:"deliveredAt" gorm:"column:deliveredAt"`
+	CreatedAt      time.Time  `json:"createdAt" gorm:"column:createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt" gorm:"column:updatedAt"`
+}
+
+func (*WebhookDelivery) TableName() string {
+	return TableNameWebhookDelivery
+}
@@ -5,7 +5,9 @@ import (
 	"path/filepath"
 	"strings"
 	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/metrics"
 	"webplus-openapi/pkg/nsc"
+	"webplus-openapi/pkg/search"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
@@ -15,6 +17,10 @@ type Config struct {
 	SourceDB *db.Config      `json:"source_db,omitempty" yaml:"sourceDB,omitempty"`
 	TargetDB *db.Config      `json:"target_db,omitempty" yaml:"targetDB,omitempty"`
 	Nats     *nsc.NatsConfig `json:"nats,omitempty" yaml:"nats,omitempty"`
+	// SearchIndex 全文检索索引，启用时恢复的文章同时写入索引
+	SearchIndex *search.Config `json:"search_index,omitempty" yaml:"searchIndex,omitempty" mapstructure:"searchIndex"`
+	// Metrics 指标监听或推送，恢复过程中可查看进度
+	Metrics *metrics.Config `json:"metrics,omitempty" yaml:"metrics,omitempty" mapstructure:"metrics"`
 }
 
 func TryLoadFromDisk(configFilePath string) (*Config, error) {
@@ -44,8 +50,10 @@ func TryLoadFromDisk(configFilePath string) (*Config, error) {
 
 func NewDefaultConfig() *Config {
 	return &Config{
-		Nats:     nsc.NewDefaultNatsConfig(),
-		SourceDB: db.NewDefaultDBConfig(),
-		TargetDB: db.NewDefaultDBConfig(),
+		Nats:        nsc.NewDefaultNatsConfig(),
+		SourceDB:    db.NewDefaultDBConfig(),
+		TargetDB:    db.NewDefaultDBConfig(),
+		SearchIndex: search.NewDefaultConfig(),
+		Metrics:     metrics.NewDefaultConfig(),
 	}
 }
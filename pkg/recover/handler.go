@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/metrics"
 	"webplus-openapi/pkg/models"
 
 	"github.com/pkg/errors"
@@ -118,6 +119,7 @@ func (as *ArticleService) ProcessArticlesConcurrently(articles []ArticleRef, par
 	totalBatches := len(batches)
 
 	zap.S().Infof("分 %d 个批次处理", totalBatches)
+	metrics.SetRecoverTotal(len(articles), totalBatches)
 
 	result := &BatchResult{}
 
@@ -155,6 +157,7 @@ func (as *ArticleService) ProcessArticlesConcurrently(articles []ArticleRef, par
 				zap.S().Errorf("批次 [%d/%d] 处理失败: %v", num, totalBatches, err)
 				// 将整个批次标记为错误
 				result.ErrorCount += len(batch)
+				metrics.AddRecoverBatch(0, 0, len(batch), true)
 				return
 			}
 
@@ -162,6 +165,7 @@ func (as *ArticleService) ProcessArticlesConcurrently(articles []ArticleRef, par
 			result.ProcessedCount += batchResult.ProcessedCount
 			result.SkippedCount += batchResult.SkippedCount
 			result.ErrorCount += batchResult.ErrorCount
+			metrics.AddRecoverBatch(batchResult.ProcessedCount, batchResult.SkippedCount, batchResult.ErrorCount, false)
 
 			// 批次完成日志
 			zap.S().Infof("批次 [%d/%d] 完成 - 处理: %d, 跳过: %d, 错误: %d",
@@ -283,6 +287,13 @@ func (as *ArticleService) processArticle(articleRef ArticleRef) ProcessResult {
 		return ProcessResult{Status: fmt.Sprintf("开启事务失败: %v", tx.Error)}
 	}
 
+	// 清理前记录文章是否已存在，变更日志据此区分新增和修改
+	var existing int64
+	if err := tx.Table(models.TableNameArticleStatic).Where("articleId = ?", articleIDInt).Count(&existing).Error; err != nil {
+		tx.Rollback()
+		return ProcessResult{Status: fmt.Sprintf("查询 article_static 失败: %v", err)}
+	}
+
 	// 先清理旧数据（幂等）
 	if err := tx.Exec("DELETE FROM article_static WHERE articleId = ?", articleIDInt).Error; err != nil {
 		tx.Rollback()
@@ -297,6 +308,16 @@ func (as *ArticleService) processArticle(articleRef ArticleRef) ProcessResult {
 		return ProcessResult{Status: fmt.Sprintf("清理 article_attachment 失败: %v", err)}
 	}
 
+	// 站群中没有修改时间的文章取发布时间，都没有时取恢复时间；详情的 Last-Modified、sortBy=lastModifyTime 依赖它
+	if articleInfo.LastModifyTime == nil {
+		if articleInfo.PublishTime != nil {
+			articleInfo.LastModifyTime = articleInfo.PublishTime
+		} else {
+			now := time.Now()
+			articleInfo.LastModifyTime = &now
+		}
+	}
+
 	// 插入 article_static
 	articleRow := map[string]interface{}{
 		"articleId":      articleIDInt,
@@ -337,7 +358,14 @@ func (as *ArticleService) processArticle(articleRef ArticleRef) ProcessResult {
 		return ProcessResult{Status: fmt.Sprintf("写入 article_static 失败: %v", err)}
 	}
 
+	// 写入扩展字段索引
+	if err := db.ReplaceArticleFieldIndex(tx, articleIDInt, articleInfo.ArticleFields); err != nil {
+		tx.Rollback()
+		return ProcessResult{Status: err.Error()}
+	}
+
 	// 插入 article_dynamic - 为每个栏目生成对应的 URL
+	columnIds := make([]int64, 0, len(articleInfo.ColumnId))
 	for i := range articleInfo.ColumnId {
 		colIDStr := articleInfo.ColumnId[i]
 		colName := ""
@@ -378,6 +406,7 @@ func (as *ArticleService) processArticle(articleRef ArticleRef) ProcessResult {
 			tx.Rollback()
 			return ProcessResult{Status: fmt.Sprintf("写入 article_dynamic 失败: %v", err)}
 		}
+		columnIds = append(columnIds, colIDInt)
 	}
 
 	// 插入附件信息
@@ -395,10 +424,24 @@ func (as *ArticleService) processArticle(articleRef ArticleRef) ProcessResult {
 		}
 	}
 
+	// 写入变更日志
+	if err := db.RecordArticleChange(tx, &models.ArticleChangeLog{
+		ArticleId: articleIDInt,
+		Op:        models.ArticleChangeUpsert,
+		SiteId:    articleInfo.SiteId,
+		ColumnIds: models.JoinColumnIds(columnIds),
+		Source:    models.ArticleChangeSourceRecover,
+		Created:   existing == 0,
+	}); err != nil {
+		tx.Rollback()
+		return ProcessResult{Status: err.Error()}
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return ProcessResult{Status: fmt.Sprintf("提交事务失败: %v", err)}
 	}
 
+	// 全文检索索引由 api 服务根据 source=recover 的变更日志更新
 	zap.S().Debugf("成功恢复文章 %s 到 targetDB.article_static/article_dynamic", articleRef.ID)
 	return ProcessResult{Status: "processed"}
 }
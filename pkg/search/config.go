@@ -0,0 +1,16 @@
+package search
+
+// Config 全文检索索引配置
+type Config struct {
+	// Enabled 是否启用内嵌全文检索索引
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// IndexPath 索引文件目录，同一目录同一时间只能被一个进程打开
+	IndexPath string `json:"indexPath" yaml:"indexPath"`
+}
+
+func NewDefaultConfig() *Config {
+	return &Config{
+		Enabled:   false,
+		IndexPath: "./data/search.bleve",
+	}
+}
@@ -0,0 +1,338 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/lang/cjk"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/html"
+	"github.com/blevesearch/bleve/v2/search/query"
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	singleton *Index
+	once      sync.Once
+)
+
+// openTimeout 等待索引目录文件锁的最长时间，超时说明索引正被其他进程（通常是 api 服务）打开
+const openTimeout = 5 * time.Second
+
+// changeLogSeqKey 索引内部保存已同步到的变更日志 seq 的 key
+var changeLogSeqKey = []byte("changeLogSeq")
+
+// Index 基于 bleve 的文章全文检索索引
+type Index struct {
+	idx bleve.Index
+}
+
+// Document 写入索引的文章文档
+type Document struct {
+	Title       string    `json:"title"`
+	Summary     string    `json:"summary"`
+	Content     string    `json:"content"`
+	SiteIds     []string  `json:"siteIds"`
+	ColumnIds   []string  `json:"columnIds"`
+	PublishTime time.Time `json:"publishTime"`
+}
+
+// Request 检索请求
+type Request struct {
+	Query     string
+	SiteIds   []string
+	ColumnIds []string
+	// ScopeSiteIds、ScopeColumnIds 调用方的可访问范围，文章属于其中任一站点或栏目即可
+	ScopeSiteIds   []string
+	ScopeColumnIds []string
+	From           int
+	Size           int
+}
+
+// Hit 单条检索结果
+type Hit struct {
+	ArticleId  int64               `json:"articleId"`
+	Score      float64             `json:"score"`
+	Highlights map[string][]string `json:"highlights"`
+}
+
+// Result 检索结果
+type Result struct {
+	Total uint64
+	Hits  []Hit
+}
+
+// Init 打开（不存在时创建）全文检索索引，未启用时直接返回
+func Init(cfg *Config) error {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	var err error
+	once.Do(func() {
+		var idx bleve.Index
+		idx, err = bleve.OpenUsing(cfg.IndexPath, map[string]interface{}{"bolt_timeout": openTimeout.String()})
+		if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+			zap.S().Infof("全文检索索引不存在，创建新索引: %s", cfg.IndexPath)
+			idx, err = bleve.New(cfg.IndexPath, newIndexMapping())
+		}
+		if errors.Is(err, bolt.ErrTimeout) {
+			err = fmt.Errorf("打开全文检索索引 %s 超时，索引正被其他进程占用（同一目录只能被一个进程打开）", cfg.IndexPath)
+			return
+		}
+		if err != nil {
+			err = fmt.Errorf("打开全文检索索引失败: %w", err)
+			return
+		}
+		singleton = &Index{idx: idx}
+		zap.S().Debug("*** 全文检索索引初始化完成 ***")
+	})
+	return err
+}
+
+// GetIndex 获取全文检索索引，未启用时返回 nil
+func GetIndex() *Index {
+	return singleton
+}
+
+// Close 关闭索引
+func (i *Index) Close() error {
+	return i.idx.Close()
+}
+
+// ChangeLogSeq 索引已同步到的变更日志 seq，未记录时返回 0
+func (i *Index) ChangeLogSeq() (int64, error) {
+	v, err := i.idx.GetInternal(changeLogSeqKey)
+	if err != nil {
+		return 0, fmt.Errorf("读取索引同步位置失败: %w", err)
+	}
+	if len(v) == 0 {
+		return 0, nil
+	}
+	return strconv.ParseInt(string(v), 10, 64)
+}
+
+// SetChangeLogSeq 记录索引已同步到的变更日志 seq，与索引数据保存在一起，重建或更换索引目录时随之重置
+func (i *Index) SetChangeLogSeq(seq int64) error {
+	if err := i.idx.SetInternal(changeLogSeqKey, []byte(strconv.FormatInt(seq, 10))); err != nil {
+		return fmt.Errorf("保存索引同步位置失败: %w", err)
+	}
+	return nil
+}
+
+func newIndexMapping() mapping.IndexMapping {
+	// 中文使用 cjk 分析器（二元切分），可同时处理中英文混排
+	textField := bleve.NewTextFieldMapping()
+	textField.Analyzer = cjk.AnalyzerName
+	textField.Store = true
+	textField.IncludeTermVectors = true
+
+	keywordField := bleve.NewKeywordFieldMapping()
+	keywordField.Store = false
+
+	dateField := bleve.NewDateTimeFieldMapping()
+
+	doc := bleve.NewDocumentMapping()
+	doc.AddFieldMappingsAt("title", textField)
+	doc.AddFieldMappingsAt("summary", textField)
+	doc.AddFieldMappingsAt("content", textField)
+	doc.AddFieldMappingsAt("siteIds", keywordField)
+	doc.AddFieldMappingsAt("columnIds", keywordField)
+	doc.AddFieldMappingsAt("publishTime", dateField)
+
+	im := bleve.NewIndexMapping()
+	im.DefaultMapping = doc
+	im.DefaultAnalyzer = cjk.AnalyzerName
+	return im
+}
+
+// IndexArticles 从 targetDB 读取文章并写入索引，targetDB 中已不存在的文章会从索引中删除
+func (i *Index) IndexArticles(targetDB *gorm.DB, articleIds []int64) error {
+	if len(articleIds) == 0 {
+		return nil
+	}
+	type staticRow struct {
+		ArticleId    int64      `gorm:"column:articleId"`
+		CreateSiteId string     `gorm:"column:createSiteId"`
+		Title        string     `gorm:"column:title"`
+		Summary      string     `gorm:"column:summary"`
+		Content      string     `gorm:"column:content"`
+		PublishTime  *time.Time `gorm:"column:publishTime"`
+	}
+	var rows []staticRow
+	if err := targetDB.Table(models.TableNameArticleStatic).
+		Select("articleId, createSiteId, title, summary, content, publishTime").
+		Where("articleId IN ?", articleIds).
+		Scan(&rows).Error; err != nil {
+		return fmt.Errorf("查询待索引文章失败: %w", err)
+	}
+	var colRows []models.Column
+	if err := targetDB.Table(models.TableNameArticleDynamic).
+		Select("articleId, columnId, siteId").
+		Where("articleId IN ?", articleIds).
+		Scan(&colRows).Error; err != nil {
+		return fmt.Errorf("查询待索引文章栏目失败: %w", err)
+	}
+	columnMap := make(map[int64][]models.Column)
+	for _, cr := range colRows {
+		columnMap[cr.ArticleId] = append(columnMap[cr.ArticleId], cr)
+	}
+
+	batch := i.idx.NewBatch()
+	found := make(map[int64]bool, len(rows))
+	for _, r := range rows {
+		found[r.ArticleId] = true
+		doc := Document{
+			Title:   r.Title,
+			Summary: r.Summary,
+			Content: util.HTMLToText(r.Content),
+		}
+		if r.PublishTime != nil {
+			doc.PublishTime = *r.PublishTime
+		}
+		siteSet := map[string]bool{}
+		if r.CreateSiteId != "" {
+			siteSet[r.CreateSiteId] = true
+		}
+		for _, cr := range columnMap[r.ArticleId] {
+			doc.ColumnIds = append(doc.ColumnIds, strconv.Itoa(cr.ColumnId))
+			if cr.SiteId != "" {
+				siteSet[cr.SiteId] = true
+			}
+		}
+		for siteId := range siteSet {
+			doc.SiteIds = append(doc.SiteIds, siteId)
+		}
+		if err := batch.Index(strconv.FormatInt(r.ArticleId, 10), doc); err != nil {
+			return fmt.Errorf("构建索引文档失败: articleId=%d, err=%w", r.ArticleId, err)
+		}
+	}
+	for _, id := range articleIds {
+		if !found[id] {
+			batch.Delete(strconv.FormatInt(id, 10))
+		}
+	}
+	if err := i.idx.Batch(batch); err != nil {
+		return fmt.Errorf("写入全文检索索引失败: %w", err)
+	}
+	return nil
+}
+
+// Delete 从索引中删除文章
+func (i *Index) Delete(articleIds ...int64) error {
+	batch := i.idx.NewBatch()
+	for _, id := range articleIds {
+		batch.Delete(strconv.FormatInt(id, 10))
+	}
+	if err := i.idx.Batch(batch); err != nil {
+		return fmt.Errorf("删除全文检索索引失败: %w", err)
+	}
+	return nil
+}
+
+// Rebuild 按 articleId 顺序分批读取 targetDB 全部文章并重建索引，返回索引的文章数；
+// 完成后把同步位置设为开始重建时的最大 seq，之后的变更由 api 服务继续同步
+func (i *Index) Rebuild(targetDB *gorm.DB, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	var startSeq int64
+	if err := targetDB.Model(&models.ArticleChangeLog{}).Select("COALESCE(MAX(seq), 0)").Scan(&startSeq).Error; err != nil {
+		return 0, fmt.Errorf("查询变更日志最大 seq 失败: %w", err)
+	}
+	var (
+		lastId int64
+		total  int
+	)
+	for {
+		var ids []int64
+		if err := targetDB.Table(models.TableNameArticleStatic).
+			Where("articleId > ?", lastId).
+			Order("articleId ASC").
+			Limit(batchSize).
+			Pluck("articleId", &ids).Error; err != nil {
+			return total, fmt.Errorf("查询文章ID失败: %w", err)
+		}
+		if len(ids) == 0 {
+			return total, i.SetChangeLogSeq(startSeq)
+		}
+		if err := i.IndexArticles(targetDB, ids); err != nil {
+			return total, err
+		}
+		total += len(ids)
+		lastId = ids[len(ids)-1]
+		zap.S().Infof("已重建 %d 篇文章的全文检索索引", total)
+	}
+}
+
+// Search 按相关度检索文章，标题、摘要、正文的权重依次降低，返回带高亮片段的结果
+func (i *Index) Search(req Request) (*Result, error) {
+	title := bleve.NewMatchQuery(req.Query)
+	title.SetField("title")
+	title.SetBoost(3)
+	summary := bleve.NewMatchQuery(req.Query)
+	summary.SetField("summary")
+	summary.SetBoost(2)
+	content := bleve.NewMatchQuery(req.Query)
+	content.SetField("content")
+
+	queries := []query.Query{bleve.NewDisjunctionQuery(title, summary, content)}
+	if len(req.SiteIds) > 0 {
+		queries = append(queries, termsQuery("siteIds", req.SiteIds))
+	}
+	if len(req.ColumnIds) > 0 {
+		queries = append(queries, termsQuery("columnIds", req.ColumnIds))
+	}
+	if len(req.ScopeSiteIds) > 0 || len(req.ScopeColumnIds) > 0 {
+		scope := bleve.NewDisjunctionQuery()
+		if len(req.ScopeSiteIds) > 0 {
+			scope.AddQuery(termsQuery("siteIds", req.ScopeSiteIds))
+		}
+		if len(req.ScopeColumnIds) > 0 {
+			scope.AddQuery(termsQuery("columnIds", req.ScopeColumnIds))
+		}
+		queries = append(queries, scope)
+	}
+
+	sr := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(queries...), req.Size, req.From, false)
+	sr.Highlight = bleve.NewHighlightWithStyle(html.Name)
+	sr.Highlight.AddField("title")
+	sr.Highlight.AddField("summary")
+	sr.Highlight.AddField("content")
+
+	res, err := i.idx.Search(sr)
+	if err != nil {
+		return nil, fmt.Errorf("全文检索失败: %w", err)
+	}
+	result := &Result{Total: res.Total, Hits: make([]Hit, 0, len(res.Hits))}
+	for _, h := range res.Hits {
+		id, err := strconv.ParseInt(h.ID, 10, 64)
+		if err != nil {
+			continue
+		}
+		result.Hits = append(result.Hits, Hit{
+			ArticleId:  id,
+			Score:      h.Score,
+			Highlights: h.Fragments,
+		})
+	}
+	return result, nil
+}
+
+// termsQuery 任一值精确匹配
+func termsQuery(field string, values []string) query.Query {
+	qs := make([]query.Query, 0, len(values))
+	for _, v := range values {
+		tq := bleve.NewTermQuery(v)
+		tq.SetField(field)
+		qs = append(qs, tq)
+	}
+	return bleve.NewDisjunctionQuery(qs...)
+}
@@ -0,0 +1,273 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// AuthConfig API Key 认证配置
+type AuthConfig struct {
+	// Enabled 是否启用认证，关闭时 /api/v1/webplus 下的接口对所有调用方开放
+	Enabled bool `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	// Keys 允许访问的 API Key 列表
+	Keys []APIKeyConfig `json:"keys,omitempty" yaml:"keys,omitempty" mapstructure:"keys"`
+}
+
+// APIKeyConfig 单个 API Key 及其可访问范围
+// SiteIds、ColumnIds 都为空时不限制范围；否则只能访问这些站点或栏目中的数据（两者取并集）
+type APIKeyConfig struct {
+	Key string `json:"key" yaml:"key" mapstructure:"key"`
+	// Name 调用方名称，用于日志、调用量统计和配额，不能重复（不区分大小写）；为空时由 Key 的摘要生成
+	Name      string   `json:"name" yaml:"name" mapstructure:"name"`
+	SiteIds   []string `json:"site_ids,omitempty" yaml:"siteIds,omitempty" mapstructure:"siteIds"`
+	ColumnIds []string `json:"column_ids,omitempty" yaml:"columnIds,omitempty" mapstructure:"columnIds"`
+	// Rate、Burst、DailyQuota 覆盖 rateLimit 中的 keyRate、keyBurst、dailyQuota，0 表示使用全局配置
+	Rate       float64 `json:"rate,omitempty" yaml:"rate,omitempty" mapstructure:"rate"`
+	Burst      int     `json:"burst,omitempty" yaml:"burst,omitempty" mapstructure:"burst"`
+	DailyQuota int64   `json:"daily_quota,omitempty" yaml:"dailyQuota,omitempty" mapstructure:"dailyQuota"`
+	// Admin 是否可以访问 /api/v1/webplus/admin 下的管理接口
+	Admin bool `json:"admin,omitempty" yaml:"admin,omitempty" mapstructure:"admin"`
+}
+
+// apiClientKey gin.Context 中保存当前调用方的键
+const apiClientKey = "webplus.apiClient"
+
+// apiClient 通过认证的调用方
+type apiClient struct {
+	id         string // Key 的摘要，每个 Key 唯一
+	scope      string // 可访问范围的摘要，范围相同的 Key 可以共用缓存
+	Name       string
+	SiteIds    []string
+	ColumnIds  []int64
+	Rate       float64
+	Burst      int
+	DailyQuota int64
+	Admin      bool
+}
+
+// restricted 是否限制了访问范围
+func (cl *apiClient) restricted() bool {
+	return cl != nil && (len(cl.SiteIds) > 0 || len(cl.ColumnIds) > 0)
+}
+
+// newAPIClients 解析配置的 API Key，以 Key 为键；Key 或名称重复、siteIds/columnIds 无效时返回错误，
+// 不能带着残缺的范围启动，否则限定了栏目的 Key 可能因范围为空而访问全部数据
+func newAPIClients(cfg *AuthConfig) (map[string]*apiClient, error) {
+	clients := make(map[string]*apiClient, len(cfg.Keys))
+	names := make(map[string]bool, len(cfg.Keys))
+	for i, k := range cfg.Keys {
+		key := strings.TrimSpace(k.Key)
+		if key == "" {
+			continue
+		}
+		if _, ok := clients[key]; ok {
+			return nil, fmt.Errorf("auth.keys[%d] 的 key 与之前的配置重复", i)
+		}
+		sum := sha256.Sum256([]byte(key))
+		cl := &apiClient{id: hex.EncodeToString(sum[:]), Name: strings.TrimSpace(k.Name), Rate: k.Rate, Burst: k.Burst, DailyQuota: k.DailyQuota, Admin: k.Admin}
+		if cl.Name == "" {
+			cl.Name = "key-" + cl.id[:8]
+		}
+		// api_usage.clientName 比较时不区分大小写
+		lower := strings.ToLower(cl.Name)
+		if names[lower] {
+			return nil, fmt.Errorf("auth.keys[%d] 的 name 重复: %s", i, cl.Name)
+		}
+		names[lower] = true
+		for _, s := range k.SiteIds {
+			s = strings.TrimSpace(s)
+			if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+				return nil, fmt.Errorf("API Key[%s] 的 siteIds 配置无效: %q", cl.Name, s)
+			}
+			cl.SiteIds = append(cl.SiteIds, s)
+		}
+		for _, s := range k.ColumnIds {
+			id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("API Key[%s] 的 columnIds 配置无效: %q", cl.Name, s)
+			}
+			cl.ColumnIds = append(cl.ColumnIds, id)
+		}
+		cl.scope = scopeDigest(cl.SiteIds, cl.ColumnIds)
+		clients[key] = cl
+	}
+	return clients, nil
+}
+
+// scopeDigest 可访问范围的摘要，与配置顺序、重复项无关；不限制范围时为 all
+func scopeDigest(siteIds []string, columnIds []int64) string {
+	if len(siteIds) == 0 && len(columnIds) == 0 {
+		return "all"
+	}
+	sites := lo.Uniq(siteIds)
+	sort.Strings(sites)
+	columns := lo.Uniq(columnIds)
+	slices.Sort(columns)
+	sum := sha256.Sum256([]byte(fmt.Sprintf("sites=%v;columns=%v", sites, columns)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// authMiddleware 校验 X-API-Key 请求头或 apiKey 参数，并把调用方写入上下文；Key 配置有误时返回错误
+func authMiddleware(cfg *AuthConfig) (gin.HandlerFunc, error) {
+	if cfg == nil || !cfg.Enabled {
+		return func(c *gin.Context) { c.Next() }, nil
+	}
+	clients, err := newAPIClients(cfg)
+	if err != nil {
+		return nil, err
+	}
+	zap.S().Infof("API Key 认证已启用，共 %d 个 Key", len(clients))
+
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			key = c.Query("apiKey")
+		}
+		cl, ok := clients[strings.TrimSpace(key)]
+		if key == "" || !ok {
+			util.Err(c, gin.H{"error": "缺少或无效的 API Key", "code": http.StatusUnauthorized})
+			return
+		}
+		c.Set(apiClientKey, cl)
+		c.Next()
+	}, nil
+}
+
+// adminMiddleware 只允许 admin 为 true 的 API Key 访问；未启用认证时管理接口不可用
+func adminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cl := currentClient(c); cl == nil || !cl.Admin {
+			util.Err(c, gin.H{"error": "管理接口需要启用认证并使用 admin API Key", "code": http.StatusForbidden})
+			return
+		}
+		c.Next()
+	}
+}
+
+// currentClient 返回当前请求的调用方，未启用认证时返回 nil
+func currentClient(c *gin.Context) *apiClient {
+	if v, ok := c.Get(apiClientKey); ok {
+		if cl, ok := v.(*apiClient); ok {
+			return cl
+		}
+	}
+	return nil
+}
+
+// dynamicCond 返回 article_dynamic 上的范围条件，alias 为表别名（可为空）
+func (cl *apiClient) dynamicCond(alias string) (string, []interface{}) {
+	if alias != "" {
+		alias += "."
+	}
+	switch {
+	case len(cl.SiteIds) > 0 && len(cl.ColumnIds) > 0:
+		return "(" + alias + "siteId IN ? OR " + alias + "columnId IN ?)", []interface{}{cl.SiteIds, cl.ColumnIds}
+	case len(cl.SiteIds) > 0:
+		return alias + "siteId IN ?", []interface{}{cl.SiteIds}
+	default:
+		return alias + "columnId IN ?", []interface{}{cl.ColumnIds}
+	}
+}
+
+// scopeArticleQuery 将文章查询限制在调用方可访问的范围内，column 为文章ID列名
+func scopeArticleQuery(c *gin.Context, targetDB *gorm.DB, query *gorm.DB, column string) *gorm.DB {
+	cl := currentClient(c)
+	if !cl.restricted() {
+		return query
+	}
+	cond, args := cl.dynamicCond("")
+	return query.Where(column+" IN (?)", targetDB.Table(models.TableNameArticleDynamic).
+		Select("articleId").
+		Where(cond, args...))
+}
+
+// scopeDynamicQuery 在已关联 article_dynamic（别名 alias）的查询上追加范围条件
+func scopeDynamicQuery(c *gin.Context, query *gorm.DB, alias string) *gorm.DB {
+	cl := currentClient(c)
+	if !cl.restricted() {
+		return query
+	}
+	cond, args := cl.dynamicCond(alias)
+	return query.Where(cond, args...)
+}
+
+// scopeColumnQuery 将 T_COLUMN 查询限制在调用方可访问的站点或栏目内
+func scopeColumnQuery(c *gin.Context, query *gorm.DB) *gorm.DB {
+	cl := currentClient(c)
+	if !cl.restricted() {
+		return query
+	}
+	switch {
+	case len(cl.SiteIds) > 0 && len(cl.ColumnIds) > 0:
+		return query.Where("(siteId IN ? OR id IN ?)", cl.SiteIds, cl.ColumnIds)
+	case len(cl.SiteIds) > 0:
+		return query.Where("siteId IN ?", cl.SiteIds)
+	default:
+		return query.Where("id IN ?", cl.ColumnIds)
+	}
+}
+
+// scopeSiteQuery 将 T_SITE 查询限制在调用方可访问的站点（含授权栏目所在站点）内
+func scopeSiteQuery(c *gin.Context, targetDB *gorm.DB, query *gorm.DB) *gorm.DB {
+	cl := currentClient(c)
+	if !cl.restricted() {
+		return query
+	}
+	columnSites := targetDB.Table(models.TableNameTColumn).Select("siteId").Where("id IN ?", cl.ColumnIds)
+	switch {
+	case len(cl.SiteIds) > 0 && len(cl.ColumnIds) > 0:
+		return query.Where("(ID IN ? OR ID IN (?))", cl.SiteIds, columnSites)
+	case len(cl.SiteIds) > 0:
+		return query.Where("ID IN ?", cl.SiteIds)
+	default:
+		return query.Where("ID IN (?)", columnSites)
+	}
+}
+
+// allowsColumn 判断栏目是否在调用方可访问范围内
+func (cl *apiClient) allowsColumn(columnId int64, siteId string) bool {
+	if !cl.restricted() {
+		return true
+	}
+	for _, id := range cl.ColumnIds {
+		if id == columnId {
+			return true
+		}
+	}
+	for _, id := range cl.SiteIds {
+		if id == siteId {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeColumnMap 去掉文章所属栏目中调用方无权访问的栏目，避免 columnInfo 泄露其他站点信息
+func scopeColumnMap(c *gin.Context, columnMap map[int64][]models.Column) {
+	cl := currentClient(c)
+	if !cl.restricted() {
+		return
+	}
+	for articleId, cols := range columnMap {
+		kept := cols[:0]
+		for _, col := range cols {
+			if cl.allowsColumn(int64(col.ColumnId), col.SiteId) {
+				kept = append(kept, col)
+			}
+		}
+		columnMap[articleId] = kept
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewAPIClients(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []APIKeyConfig
+		wantErr string
+	}{
+		{
+			name: "ok",
+			keys: []APIKeyConfig{{Key: "k1", Name: "a", SiteIds: []string{"12"}}, {Key: "k2", ColumnIds: []string{" 34 "}}},
+		},
+		{
+			name:    "duplicate name ignores case",
+			keys:    []APIKeyConfig{{Key: "k1", Name: "CS"}, {Key: "k2", Name: "cs"}},
+			wantErr: "name 重复",
+		},
+		{
+			name:    "duplicate key",
+			keys:    []APIKeyConfig{{Key: "k1", Name: "a"}, {Key: " k1 ", Name: "b"}},
+			wantErr: "key 与之前的配置重复",
+		},
+		{
+			name:    "bad column id",
+			keys:    []APIKeyConfig{{Key: "k1", ColumnIds: []string{"x"}}},
+			wantErr: "columnIds 配置无效",
+		},
+		{
+			name:    "bad site id",
+			keys:    []APIKeyConfig{{Key: "k1", SiteIds: []string{""}}},
+			wantErr: "siteIds 配置无效",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newAPIClients(&AuthConfig{Enabled: true, Keys: tt.keys})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewAPIClientsDefaultName(t *testing.T) {
+	clients, err := newAPIClients(&AuthConfig{Keys: []APIKeyConfig{{Key: "abcd-1"}, {Key: "abcd-2"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := clients["abcd-1"], clients["abcd-2"]
+	if a.Name == b.Name {
+		t.Fatalf("keys with the same prefix share name %q", a.Name)
+	}
+	if !strings.HasPrefix(a.Name, "key-") || strings.Contains(a.Name, "abcd") {
+		t.Fatalf("default name %q should not expose the key", a.Name)
+	}
+	if len(clients["abcd-2"].ColumnIds) != 0 || clients["abcd-2"].restricted() {
+		t.Fatal("key without scope should be unrestricted")
+	}
+}
+
+func TestScopeDigest(t *testing.T) {
+	a := scopeDigest([]string{"12", "3"}, []int64{7, 5})
+	if b := scopeDigest([]string{"3", "12", "3"}, []int64{5, 7}); a != b {
+		t.Fatalf("same scope in different order: %s != %s", a, b)
+	}
+	if b := scopeDigest([]string{"12"}, []int64{5, 7}); a == b {
+		t.Fatal("different scopes share a digest")
+	}
+	if b := scopeDigest([]string{"12", "3"}, nil); a == b {
+		t.Fatal("different scopes share a digest")
+	}
+	if d := scopeDigest(nil, nil); d != "all" {
+		t.Fatalf("unrestricted digest = %q", d)
+	}
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBatchArticleIds getArticlesByIds 单次最多查询的文章数
+const maxBatchArticleIds = 200
+
+// GetArticlesByIds 按ID批量获取文章
+// @Summary      按ID批量获取文章
+// @Description  按传入顺序返回文章，字段与 getArticles 一致；不存在或无权访问的ID放在 missing 中
+// @Tags         articles
+// @Produce      json
+// @Param        articleIds query  string  true   "文章ID，逗号分隔，重复的ID只返回一次，最多 200 个；POST JSON 请求体中可以传数组"
+// @Param        columnId   query  string  false  "栏目ID，文章属于该栏目时使用该栏目的访问地址作为 visitUrl"
+// @Param        fields     query  string  false  "只返回这些字段，逗号分隔；articleId 始终返回"
+// @Param        excludeFields query string false "不返回这些字段，逗号分隔"
+// @Param        contentFormat query string false "正文格式: html(默认)、text（纯文本）"
+// @Param        absoluteUrls  query bool   false "为 true 时将正文 src/href、封面图、附件中的站内地址补全为站点域名下的绝对地址"
+// @Param        excerpt       query int    false "summary 为空时从正文生成该长度（字符）的摘要，最大 1000"
+// @Success      200  {object}  util.Response
+// @Failure      400  {object}  util.Response
+// @Router       /api/v1/webplus/getArticlesByIds [get]
+// @Router       /api/v1/webplus/getArticlesByIds [post]
+func (h *Handler) GetArticlesByIds(c *gin.Context) {
+	ids, err := parseBatchArticleIds(util.GetParam(c, "articleIds"))
+	if err != nil {
+		util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+		return
+	}
+	proj, err := h.parseFieldProjection(c)
+	if err != nil {
+		util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+		return
+	}
+
+	targetDB := db.GetTargetDB()
+	if targetDB == nil {
+		util.Err(c, fmt.Errorf("targetDB 未初始化"))
+		return
+	}
+
+	var rows []articleRow
+	if err := scopeArticleQuery(c, targetDB, targetDB.Table(models.TableNameArticleStatic), "articleId").
+		Where("articleId IN ?", ids).
+		Select(proj.selectColumns(articleSort{})).
+		Scan(&rows).Error; err != nil {
+		util.Err(c, fmt.Errorf("批量查询文章失败: %v", err))
+		return
+	}
+
+	// 按请求顺序排列，记录不存在的ID
+	byId := make(map[int64]articleRow, len(rows))
+	for _, r := range rows {
+		byId[r.ArticleId] = r
+	}
+	ordered := make([]articleRow, 0, len(rows))
+	found := make([]int64, 0, len(rows))
+	missing := make([]string, 0)
+	for _, id := range ids {
+		if r, ok := byId[id]; ok {
+			ordered = append(ordered, r)
+			found = append(found, id)
+		} else {
+			missing = append(missing, strconv.FormatInt(id, 10))
+		}
+	}
+
+	columnMap, attachMap, err := loadArticleRelations(targetDB, found)
+	if err != nil {
+		util.Err(c, err)
+		return
+	}
+	scopeColumnMap(c, columnMap)
+
+	list := h.buildArticleItems(ordered, columnMap, attachMap, util.GetParam(c, "columnId"), proj)
+	util.Ok(c, gin.H{
+		"found":   len(list) > 0,
+		"items":   list,
+		"missing": missing,
+	})
+}
+
+// parseBatchArticleIds 解析逗号分隔的文章ID，保持顺序并去重；包含非数字ID或超过数量上限时返回错误
+func parseBatchArticleIds(s string) ([]int64, error) {
+	var (
+		ids  []int64
+		seen = make(map[int64]bool)
+	)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("articleIds 必须为数字: %s", part)
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("articleIds 不能为空")
+	}
+	if len(ids) > maxBatchArticleIds {
+		return nil, fmt.Errorf("articleIds 最多 %d 个，实际 %d 个", maxBatchArticleIds, len(ids))
+	}
+	return ids, nil
+}
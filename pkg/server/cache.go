@@ -0,0 +1,444 @@
+package server
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// CacheConfig 接口响应缓存配置
+type CacheConfig struct {
+	// Enabled 是否启用 getArticles、getColumns、getSites 的响应缓存
+	Enabled bool `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	// TTL 缓存有效期（秒），默认 60
+	TTL int `json:"ttl,omitempty" yaml:"ttl,omitempty" mapstructure:"ttl"`
+	// MaxEntries 最多缓存的响应数，超出时淘汰最久未使用的，默认 1000
+	MaxEntries int `json:"max_entries,omitempty" yaml:"maxEntries,omitempty" mapstructure:"maxEntries"`
+}
+
+const (
+	// cacheMaxBodySize 超过该大小的响应不缓存，避免带正文的大分页占满内存
+	cacheMaxBodySize = 1 << 20
+	// syncStatePollInterval 检查 table sync 是否有变更的间隔
+	syncStatePollInterval = 10 * time.Second
+	// cacheTagAll 未按站点、栏目过滤的文章列表，任何文章变更都会淘汰
+	cacheTagAll = "*"
+)
+
+// cacheTagSite、cacheTagColumn 生成按站点、栏目淘汰缓存使用的标签
+func cacheTagSite(siteId string) string     { return "site:" + siteId }
+func cacheTagColumn(columnId string) string { return "column:" + columnId }
+
+type cacheEntry struct {
+	key         string
+	contentType string
+	etag        string
+	modified    time.Time
+	body        []byte
+	tags        []string
+	expiresAt   time.Time
+}
+
+// responseCache 带过期时间的 LRU 响应缓存
+// 文章变更时按标签淘汰，table sync 有变更时整体清空
+type responseCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	ll         *list.List
+	entries    map[string]*list.Element
+	tagIndex   map[string]map[string]struct{} // 标签 -> 缓存 key
+	// gen 每次淘汰、清空时递增；evictedAt 记录标签最近一次被淘汰时的 gen，purgedAt 记录最近一次清空时的 gen。
+	// 未命中时先取 gen 再查询数据库，写入前若相关标签在此之后被淘汰过，说明查询结果可能已过时，不再写入
+	gen       uint64
+	evictedAt map[string]uint64
+	purgedAt  uint64
+}
+
+var (
+	respCache     *responseCache
+	respCacheOnce sync.Once
+)
+
+// initResponseCache 按配置创建进程内唯一的响应缓存，未启用时返回 nil
+func initResponseCache(cfg *CacheConfig) *responseCache {
+	respCacheOnce.Do(func() {
+		if cfg == nil || !cfg.Enabled {
+			return
+		}
+		ttl := time.Duration(cfg.TTL) * time.Second
+		if ttl <= 0 {
+			ttl = time.Minute
+		}
+		maxEntries := cfg.MaxEntries
+		if maxEntries <= 0 {
+			maxEntries = 1000
+		}
+		respCache = &responseCache{
+			ttl:        ttl,
+			maxEntries: maxEntries,
+			ll:         list.New(),
+			entries:    make(map[string]*list.Element),
+			tagIndex:   make(map[string]map[string]struct{}),
+			evictedAt:  make(map[string]uint64),
+		}
+		zap.S().Infof("接口响应缓存已启用，有效期 %v，最多 %d 条", ttl, maxEntries)
+	})
+	return respCache
+}
+
+func (rc *responseCache) get(key string) (*cacheEntry, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	el, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		rc.removeElement(el)
+		return nil, false
+	}
+	rc.ll.MoveToFront(el)
+	return entry, true
+}
+
+// generation 当前的淘汰版本，在查询数据库之前取得，写入缓存时传给 set
+func (rc *responseCache) generation() uint64 {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.gen
+}
+
+// set 写入缓存；since 之后缓存被清空或 entry 的任一标签被淘汰过时丢弃，避免把淘汰前查询到的旧数据写回缓存
+func (rc *responseCache) set(entry *cacheEntry, since uint64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.purgedAt > since {
+		return
+	}
+	for _, tag := range entry.tags {
+		if rc.evictedAt[tag] > since {
+			return
+		}
+	}
+	if el, ok := rc.entries[entry.key]; ok {
+		rc.removeElement(el)
+	}
+	entry.expiresAt = time.Now().Add(rc.ttl)
+	rc.entries[entry.key] = rc.ll.PushFront(entry)
+	for _, tag := range entry.tags {
+		keys, ok := rc.tagIndex[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			rc.tagIndex[tag] = keys
+		}
+		keys[entry.key] = struct{}{}
+	}
+	for rc.ll.Len() > rc.maxEntries {
+		rc.removeElement(rc.ll.Back())
+	}
+}
+
+// removeElement 删除缓存项及其标签索引，调用方需持有锁
+func (rc *responseCache) removeElement(el *list.Element) {
+	entry := el.Value.(*cacheEntry)
+	rc.ll.Remove(el)
+	delete(rc.entries, entry.key)
+	for _, tag := range entry.tags {
+		if keys, ok := rc.tagIndex[tag]; ok {
+			delete(keys, entry.key)
+			if len(keys) == 0 {
+				delete(rc.tagIndex, tag)
+			}
+		}
+	}
+}
+
+// evict 淘汰带有任一标签的缓存，同时淘汰未按站点、栏目过滤的文章列表
+func (rc *responseCache) evict(tags ...string) int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.gen++
+	n := 0
+	for _, tag := range append(tags, cacheTagAll) {
+		rc.evictedAt[tag] = rc.gen
+		for key := range rc.tagIndex[tag] {
+			if el, ok := rc.entries[key]; ok {
+				rc.removeElement(el)
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// purge 清空全部缓存
+func (rc *responseCache) purge() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.gen++
+	rc.purgedAt = rc.gen
+	rc.ll.Init()
+	rc.entries = make(map[string]*list.Element)
+	rc.tagIndex = make(map[string]map[string]struct{})
+}
+
+// cacheKey 由路径、调用方的访问范围和规范化后的参数组成，参数顺序、GET/POST 方式不影响命中；
+// 调用方名称不唯一也不代表范围，不能用于区分缓存
+func cacheKey(c *gin.Context) string {
+	// 触发 gin 解析表单，之后 Request.Form 中同时包含 query 和表单参数
+	_ = c.PostForm("")
+	params := make(url.Values, len(c.Request.Form))
+	for k, vs := range c.Request.Form {
+		if k == "apiKey" {
+			continue
+		}
+		kept := make([]string, 0, len(vs))
+		for _, v := range vs {
+			if v = strings.TrimSpace(v); v != "" {
+				kept = append(kept, v)
+			}
+		}
+		if len(kept) > 0 {
+			params[k] = kept
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString(c.Request.URL.Path)
+	sb.WriteString("|")
+	sb.WriteString(cacheScope(c))
+	sb.WriteString("|")
+	// Encode 按参数名排序，参数顺序不同也能命中
+	sb.WriteString(params.Encode())
+	// JSON 请求体按字段名排序并去掉空白后参与计算
+	if body := util.JSONBodyValues(c); len(body) > 0 {
+		values := make(map[string]json.RawMessage, len(body))
+		for k, v := range body {
+			if k != "apiKey" {
+				values[k] = v
+			}
+		}
+		if b, err := json.Marshal(values); err == nil {
+			sb.WriteString("|")
+			sb.Write(b)
+		}
+	}
+	return sb.String()
+}
+
+// cacheScope 当前调用方访问范围的摘要，未启用认证或不限制范围时为 all
+func cacheScope(c *gin.Context) string {
+	if cl := currentClient(c); cl != nil && cl.scope != "" {
+		return cl.scope
+	}
+	return "all"
+}
+
+// cacheWriter 在写出响应的同时保留一份响应体
+type cacheWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *cacheWriter) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheWriter) WriteString(s string) (int, error) {
+	w.buf.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// cached 缓存接口的成功响应，tagsOf 返回缓存项的淘汰标签（为空时只在过期或 table sync 变更时淘汰）
+func cached(tagsOf func(c *gin.Context) []string, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rc := respCache
+		if rc == nil {
+			handler(c)
+			return
+		}
+		key := cacheKey(c)
+		if entry, ok := rc.get(key); ok {
+			c.Header("X-Cache", "HIT")
+			if util.NotModified(c, entry.etag, entry.modified) {
+				return
+			}
+			c.Data(http.StatusOK, entry.contentType, entry.body)
+			return
+		}
+
+		c.Header("X-Cache", "MISS")
+		gen := rc.generation()
+		w := &cacheWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		handler(c)
+		c.Writer = w.ResponseWriter
+
+		if c.IsAborted() || w.Status() != http.StatusOK || w.buf.Len() > cacheMaxBodySize {
+			return
+		}
+		modified, _ := http.ParseTime(w.Header().Get("Last-Modified"))
+		rc.set(&cacheEntry{
+			key:         key,
+			contentType: w.Header().Get("Content-Type"),
+			etag:        w.Header().Get("ETag"),
+			modified:    modified,
+			body:        w.buf.Bytes(),
+			tags:        tagsOf(c),
+		}, gen)
+	}
+}
+
+// articleListCacheTags getArticles 的缓存标签：按栏目过滤时为栏目，按站点过滤时为站点，否则任何文章变更都淘汰
+func articleListCacheTags(c *gin.Context) []string {
+	if columnIds, _ := parseIDList(articleColumnParam(c)); len(columnIds) > 0 {
+		tags := make([]string, 0, len(columnIds))
+		for _, id := range columnIds {
+			tags = append(tags, cacheTagColumn(strconv.FormatInt(id, 10)))
+		}
+		return tags
+	}
+	if siteIds, _ := parseIDList(articleSiteParam(c)); len(siteIds) > 0 {
+		tags := make([]string, 0, len(siteIds))
+		for _, id := range siteIds {
+			tags = append(tags, cacheTagSite(strconv.FormatInt(id, 10)))
+		}
+		return tags
+	}
+	return []string{cacheTagAll}
+}
+
+// allCacheTags 结果涉及的站点、栏目在查询前无法确定，任意文章变更都淘汰
+func allCacheTags(*gin.Context) []string { return []string{cacheTagAll} }
+
+// noCacheTags 栏目、站点数据只由 table sync 更新，不随文章变更淘汰
+func noCacheTags(*gin.Context) []string { return nil }
+
+// articleCacheTags 查询文章当前所在的站点、栏目及栏目的全部上级栏目，用于淘汰缓存
+// 需在文章数据变更前调用，变更后的新栏目由调用方补充
+func articleCacheTags(targetDB *gorm.DB, articleId int64) []string {
+	if respCache == nil || targetDB == nil {
+		return nil
+	}
+	var rows []struct {
+		SiteId   string `gorm:"column:siteId"`
+		ColumnId int64  `gorm:"column:columnId"`
+	}
+	if err := targetDB.Table(models.TableNameArticleDynamic).
+		Select("siteId, columnId").
+		Where("articleId = ?", articleId).
+		Scan(&rows).Error; err != nil {
+		zap.S().Warnf("查询文章所在栏目失败: articleId=%d, err=%v", articleId, err)
+		return nil
+	}
+	var tags []string
+	columnIds := make([]int64, 0, len(rows))
+	for _, r := range rows {
+		if r.SiteId != "" {
+			tags = append(tags, cacheTagSite(r.SiteId))
+		}
+		columnIds = append(columnIds, r.ColumnId)
+	}
+	return append(tags, columnCacheTags(targetDB, columnIds)...)
+}
+
+// columnCacheTags 栏目及其全部上级栏目的缓存标签，includeChildren 查询上级栏目时也包含子栏目文章
+func columnCacheTags(targetDB *gorm.DB, columnIds []int64) []string {
+	if respCache == nil || len(columnIds) == 0 {
+		return nil
+	}
+	tags := make([]string, 0, len(columnIds))
+	for _, id := range columnIds {
+		tags = append(tags, cacheTagColumn(strconv.FormatInt(id, 10)))
+	}
+	if targetDB == nil {
+		return tags
+	}
+	var paths []string
+	if err := targetDB.Table(models.TableNameTColumn).
+		Where("id IN ?", columnIds).
+		Pluck("path", &paths).Error; err != nil {
+		zap.S().Warnf("查询栏目路径失败: columnIds=%v, err=%v", columnIds, err)
+		return tags
+	}
+	for _, p := range paths {
+		for _, part := range strings.Split(strings.Trim(p, "/"), "/") {
+			if part != "" {
+				tags = append(tags, cacheTagColumn(part))
+			}
+		}
+	}
+	return tags
+}
+
+// evictResponseCache 按标签淘汰响应缓存，未启用缓存时不做任何事
+func evictResponseCache(tags []string) {
+	if respCache == nil {
+		return
+	}
+	n := respCache.evict(tags...)
+	zap.S().Debugf("淘汰响应缓存 %d 条: tags=%v", n, tags)
+}
+
+// watchSyncState 定期检查 sync_state 表，table sync 有变更时清空缓存
+func (rc *responseCache) watchSyncState(ctx context.Context) error {
+	var (
+		last        time.Time
+		initialized bool
+	)
+	poll := func() {
+		targetDB := db.GetTargetDB()
+		if targetDB == nil {
+			return
+		}
+		var latest *time.Time
+		if err := targetDB.Table(models.TableNameSyncState).
+			Select("MAX(lastChangeTime)").
+			Scan(&latest).Error; err != nil {
+			zap.S().Warnf("查询同步状态失败: %v", err)
+			return
+		}
+		// 首次检查只记录基准时间
+		if !initialized {
+			initialized = true
+			if latest != nil {
+				last = *latest
+			}
+			return
+		}
+		if latest == nil || !latest.After(last) {
+			return
+		}
+		rc.purge()
+		last = *latest
+		zap.S().Infof("table sync 有变更（%s），已清空响应缓存", latest.Format(time.DateTime))
+	}
+
+	poll()
+	ticker := time.NewTicker(syncStatePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			poll()
+		}
+	}
+}
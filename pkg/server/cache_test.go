@@ -0,0 +1,45 @@
+package server
+
+import (
+	"container/list"
+	"testing"
+	"time"
+)
+
+func newTestResponseCache() *responseCache {
+	return &responseCache{
+		ttl:        time.Minute,
+		maxEntries: 10,
+		ll:         list.New(),
+		entries:    make(map[string]*list.Element),
+		tagIndex:   make(map[string]map[string]struct{}),
+		evictedAt:  make(map[string]uint64),
+	}
+}
+
+func TestResponseCacheSetAfterEvict(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		evict   func(rc *responseCache)
+		wantHit bool
+	}{
+		{"no eviction", []string{cacheTagSite("1")}, func(rc *responseCache) {}, true},
+		{"own tag evicted", []string{cacheTagSite("1")}, func(rc *responseCache) { rc.evict(cacheTagSite("1")) }, false},
+		{"other tag evicted", []string{cacheTagSite("1")}, func(rc *responseCache) { rc.evict(cacheTagSite("2")) }, true},
+		{"unfiltered list evicted by any change", []string{cacheTagAll}, func(rc *responseCache) { rc.evict(cacheTagSite("2")) }, false},
+		{"purged", nil, func(rc *responseCache) { rc.purge() }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := newTestResponseCache()
+			// 模拟未命中时：取 gen -> 查询期间发生淘汰 -> 写入
+			gen := rc.generation()
+			tt.evict(rc)
+			rc.set(&cacheEntry{key: "k", body: []byte("{}"), tags: tt.tags}, gen)
+			if _, ok := rc.get("k"); ok != tt.wantHit {
+				t.Fatalf("hit = %v, want %v", ok, tt.wantHit)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+// changes 接口每次返回的默认、最大事件数
+const (
+	defaultChangesLimit = 100
+	maxChangesLimit     = 1000
+)
+
+// ChangeEvent 文章变更事件，op 为 delete 时表示文章已删除（墓碑）
+type ChangeEvent struct {
+	Seq       int64    `json:"seq"`
+	ArticleId string   `json:"articleId"`
+	Op        string   `json:"op"` // upsert、delete、column_add、column_remove
+	SiteId    string   `json:"siteId"`
+	ColumnId  string   `json:"columnId,omitempty"` // column_add、column_remove 变更的栏目
+	ColumnIds []string `json:"columnIds"`          // 变更时文章所在的栏目，删除事件为删除前的栏目
+	Source    string   `json:"source"`             // nats、recover
+	Created   bool     `json:"created,omitempty"`  // upsert 时文章此前不存在
+	Time      string   `json:"time"`
+}
+
+// newChangeEvent 将变更日志转换为接口返回的事件
+func newChangeEvent(l models.ArticleChangeLog) ChangeEvent {
+	e := ChangeEvent{
+		Seq:       l.Seq,
+		ArticleId: strconv.FormatInt(l.ArticleId, 10),
+		Op:        l.Op,
+		SiteId:    l.SiteId,
+		ColumnIds: []string{},
+		Source:    l.Source,
+		Created:   l.Created,
+		Time:      l.CreatedAt.Format(time.DateTime),
+	}
+	if l.ColumnId != 0 {
+		e.ColumnId = strconv.FormatInt(l.ColumnId, 10)
+	}
+	if l.ColumnIds != "" {
+		e.ColumnIds = strings.Split(l.ColumnIds, ",")
+	}
+	return e
+}
+
+// GetChanges 增量变更
+// @Summary      增量变更
+// @Description  按 seq 升序返回 since 之后的文章变更事件，包括新增/修改、删除（墓碑）、栏目添加/移除；下一次请求传返回的 nextSince 即可继续拉取
+// @Tags         articles
+// @Produce      json
+// @Param        since  query  int  false  "从该 seq 之后开始返回（不含），默认 0"
+// @Param        limit  query  int  false  "返回条数，默认 100，最大 1000"
+// @Success      200  {object}  util.Response
+// @Failure      400  {object}  util.Response
+// @Router       /api/v1/webplus/changes [get]
+func (h *Handler) GetChanges(c *gin.Context) {
+	var since int64
+	if s := util.GetParam(c, "since"); s != "" {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || v < 0 {
+			util.Err(c, gin.H{"error": fmt.Sprintf("since 必须为非负整数: %s", s), "code": http.StatusBadRequest})
+			return
+		}
+		since = v
+	}
+	limit := defaultChangesLimit
+	if s := util.GetParam(c, "limit"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 1 || v > maxChangesLimit {
+			util.Err(c, gin.H{"error": fmt.Sprintf("limit 必须为 1-%d 的整数: %s", maxChangesLimit, s), "code": http.StatusBadRequest})
+			return
+		}
+		limit = v
+	}
+
+	targetDB := db.GetTargetDB()
+	if targetDB == nil {
+		util.Err(c, fmt.Errorf("targetDB 未初始化"))
+		return
+	}
+
+	logs, err := queryArticleChanges(targetDB, currentClient(c), since, limit+1)
+	if err != nil {
+		util.Err(c, err)
+		return
+	}
+	hasMore := len(logs) > limit
+	if hasMore {
+		logs = logs[:limit]
+	}
+
+	items := make([]ChangeEvent, 0, len(logs))
+	nextSince := since
+	for _, l := range logs {
+		items = append(items, newChangeEvent(l))
+		nextSince = l.Seq
+	}
+	util.Ok(c, gin.H{
+		"items":     items,
+		"nextSince": nextSince,
+		"hasMore":   hasMore,
+	})
+}
+
+// recordArticleChange 在文章变更的事务中写入变更日志，并为匹配的 webhook 订阅写入待投递记录，三者一起提交或回滚
+func recordArticleChange(tx *gorm.DB, change *models.ArticleChangeLog) error {
+	if err := db.RecordArticleChange(tx, change); err != nil {
+		return err
+	}
+	return enqueueWebhookDeliveries(tx, change)
+}
+
+// publishArticleChange 文章变更事务提交后调用，唤醒 webhook 投递并把变更推送给 /events 连接
+func publishArticleChange(change *models.ArticleChangeLog) {
+	if webhooks != nil {
+		webhooks.notify()
+	}
+	events.publish(change)
+}
+
+// changeMatches 变更是否在站点、栏目、变更类型范围内：siteIds、columnIds 都为空时不限制，否则两者取并集；ops 为空时不限制
+func changeMatches(siteIds, columnIds, ops []string, change *models.ArticleChangeLog) bool {
+	if len(ops) > 0 && !lo.Contains(ops, change.Op) {
+		return false
+	}
+	if len(siteIds) == 0 && len(columnIds) == 0 {
+		return true
+	}
+	if lo.Contains(siteIds, change.SiteId) {
+		return true
+	}
+	return len(lo.Intersect(columnIds, splitIds(change.ColumnIds))) > 0
+}
+
+// queryArticleChanges 按 seq 升序查询 since 之后调用方可访问的变更日志
+func queryArticleChanges(targetDB *gorm.DB, cl *apiClient, since int64, limit int) ([]models.ArticleChangeLog, error) {
+	query := targetDB.Model(&models.ArticleChangeLog{}).Where("seq > ?", since)
+	if cl.restricted() {
+		cond, args := cl.changeLogCond()
+		query = query.Where(cond, args...)
+	}
+	var logs []models.ArticleChangeLog
+	if err := query.Order("seq ASC").Limit(limit).Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("查询变更日志失败: %v", err)
+	}
+	return logs, nil
+}
+
+// changeLogCond 返回 article_change_log 上的范围条件：站点匹配，或变更时文章所在的栏目中有可访问的栏目
+func (cl *apiClient) changeLogCond() (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if len(cl.SiteIds) > 0 {
+		conds = append(conds, "siteId IN ?")
+		args = append(args, cl.SiteIds)
+	}
+	for _, id := range cl.ColumnIds {
+		conds = append(conds, "FIND_IN_SET(?, columnIds) > 0")
+		args = append(args, strconv.FormatInt(id, 10))
+	}
+	return "(" + strings.Join(conds, " OR ") + ")", args
+}
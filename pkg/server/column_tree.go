@@ -0,0 +1,172 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+// ColumnNode 栏目树节点
+type ColumnNode struct {
+	ColumnInfo
+	SiteId   int          `json:"siteId"`   // 站点ID
+	Children []ColumnNode `json:"children"` // 子栏目
+}
+
+// GetColumnTreeResponse showType=tree 时的响应结构体
+type GetColumnTreeResponse struct {
+	Found bool         `json:"found"` // 是否找到数据
+	Items []ColumnNode `json:"items"` // 根栏目列表，子栏目在 children 中
+	Total int          `json:"total"` // 树中栏目总数
+}
+
+// getColumnTree 一次查询站点的全部栏目，在内存中按 parentId 组装为嵌套的栏目树
+// 参数: siteId（必填）、parentId（根节点，默认为站点顶级栏目）、depth（层级限制，1 表示只返回根节点）、navigation（按是否导航过滤）
+// navigation 过滤掉的栏目不返回，其下符合条件的子孙栏目挂到最近的返回的祖先下（parentColumnId 仍为实际的父栏目），depth 按返回的层级计算
+func (h *Handler) getColumnTree(c *gin.Context, targetDB *gorm.DB) {
+	siteIdStr := util.GetParam(c, "siteId")
+	validSiteIds, _ := parseIDList(siteIdStr)
+	if len(validSiteIds) == 0 {
+		util.Err(c, gin.H{"error": "showType=tree 时 siteId 必填", "code": http.StatusBadRequest})
+		return
+	}
+
+	parentId := 0
+	if s := util.GetParam(c, "parentId"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			util.Err(c, gin.H{"error": fmt.Sprintf("parentId 必须为数字: %s", s), "code": http.StatusBadRequest})
+			return
+		}
+		parentId = v
+	}
+	depth := 0
+	if s := util.GetParam(c, "depth"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			util.Err(c, gin.H{"error": fmt.Sprintf("depth 必须为非负整数: %s", s), "code": http.StatusBadRequest})
+			return
+		}
+		depth = v
+	}
+	navigationFilter := -1
+	if s := util.GetParam(c, "navigation"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			util.Err(c, gin.H{"error": fmt.Sprintf("navigation 必须为数字: %s", s), "code": http.StatusBadRequest})
+			return
+		}
+		navigationFilter = v
+	}
+
+	siteIds := make([]string, 0, len(validSiteIds))
+	for _, id := range validSiteIds {
+		siteIds = append(siteIds, strconv.FormatInt(id, 10))
+	}
+	var columns []models.TColumn
+	if err := scopeColumnQuery(c, targetDB.Table(models.TableNameTColumn)).
+		Where("siteId IN ?", siteIds).
+		Order("sort ASC, id ASC").
+		Find(&columns).Error; err != nil {
+		util.Err(c, fmt.Errorf("查询栏目列表失败: %v", err))
+		return
+	}
+
+	// id -> 名称，用于生成中文路径；path 中不属于这些站点的栏目再统一补查一次
+	columnIdToName := make(map[int]string, len(columns))
+	for i := range columns {
+		columnIdToName[columns[i].Id] = columns[i].Name
+	}
+	// 调用方只能访问部分栏目时，父栏目不可见的栏目挂到默认根节点下
+	orphanToRoot := currentClient(c).restricted() && parentId == 0
+	childrenOf := make(map[int][]*models.TColumn)
+	for i := range columns {
+		col := &columns[i]
+		pid := col.ParentId
+		if _, ok := columnIdToName[pid]; !ok && orphanToRoot {
+			pid = 0
+		}
+		childrenOf[pid] = append(childrenOf[pid], col)
+	}
+	var missingIds []int
+	missing := make(map[int]bool)
+	for i := range columns {
+		for _, id := range h.extractIdsFromPath(columns[i].Path) {
+			if _, ok := columnIdToName[id]; !ok && !missing[id] {
+				missing[id] = true
+				missingIds = append(missingIds, id)
+			}
+		}
+	}
+	if len(missingIds) > 0 {
+		var pathColumns []models.TColumn
+		if err := targetDB.Table(models.TableNameTColumn).
+			Where("id IN ?", missingIds).
+			Select("id, name").
+			Find(&pathColumns).Error; err == nil {
+			for _, pathCol := range pathColumns {
+				columnIdToName[pathCol.Id] = pathCol.Name
+			}
+		}
+	}
+
+	// 站点域名批量查询
+	domains := loadSiteDomainsById(targetDB, lo.Map(validSiteIds, func(id int64, _ int) int { return int(id) }))
+	columnUrl := func(col *models.TColumn) string {
+		if col.Link != "" {
+			return col.Link
+		}
+		return buildColumnUrl(domains[col.SiteId], col.UrlName)
+	}
+
+	total := 0
+	visited := make(map[int]bool)
+	var build func(pid int, level int) []ColumnNode
+	build = func(pid int, level int) []ColumnNode {
+		nodes := make([]ColumnNode, 0, len(childrenOf[pid]))
+		for _, col := range childrenOf[pid] {
+			// 与原 tree 模式一致，id 为 1 的系统根栏目不作为节点返回
+			if col.Id == 1 || visited[col.Id] {
+				continue
+			}
+			visited[col.Id] = true
+			if navigationFilter >= 0 && col.Navigation != navigationFilter {
+				// 子孙栏目提升到当前层级
+				nodes = append(nodes, build(col.Id, level)...)
+				continue
+			}
+			total++
+			node := ColumnNode{
+				ColumnInfo: ColumnInfo{
+					ColumnId:       col.Id,
+					ColumnName:     col.Name,
+					ParentColumnId: col.ParentId,
+					ColumnUrl:      columnUrl(col),
+					Path:           h.convertPathToChineseWithCache(col.Path, columnIdToName, col, h.extractIdsFromPath(col.Path)),
+					Sort:           col.Sort,
+					Status:         col.Navigation,
+				},
+				SiteId:   col.SiteId,
+				Children: []ColumnNode{},
+			}
+			if depth == 0 || level < depth {
+				node.Children = build(col.Id, level+1)
+			}
+			nodes = append(nodes, node)
+		}
+		return nodes
+	}
+	items := build(parentId, 1)
+
+	util.Ok(c, GetColumnTreeResponse{
+		Found: len(items) > 0,
+		Items: items,
+		Total: total,
+	})
+}
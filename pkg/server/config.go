@@ -5,7 +5,9 @@ import (
 	"path/filepath"
 	"strings"
 	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/metrics"
 	"webplus-openapi/pkg/nsc"
+	"webplus-openapi/pkg/search"
 	"webplus-openapi/pkg/util"
 
 	"github.com/pkg/errors"
@@ -15,11 +17,21 @@ import (
 type Config struct {
 	ClientName     string                `json:"client_name" yaml:"clientName"`
 	Port           int                   `json:"port,omitempty" yaml:"port,omitempty" mapstructure:"port"`
+	TrustedProxies []string              `json:"trusted_proxies,omitempty" yaml:"trustedProxies,omitempty" mapstructure:"trustedProxies"` // 可信的反向代理，只有来自这些地址的 X-Forwarded-For 才用于确定客户端 IP
 	SourceDB       *db.Config            `json:"source_db,omitempty" yaml:"sourceDB,omitempty"`
 	TargetDB       *db.Config            `json:"target_db,omitempty" yaml:"targetDB,omitempty"`
 	Nats           *nsc.NatsConfig       `json:"nats,omitempty" yaml:"nats,omitempty" mapstructure:"nats"`
 	ResponseFields *ResponseFieldsConfig `json:"response_fields,omitempty" yaml:"response_fields,omitempty" mapstructure:"response_fields"`
 	Search         *SearchConfig         `json:"search,omitempty" yaml:"search,omitempty" mapstructure:"search"`
+	SearchIndex    *search.Config        `json:"search_index,omitempty" yaml:"searchIndex,omitempty" mapstructure:"searchIndex"`
+	Auth           *AuthConfig           `json:"auth,omitempty" yaml:"auth,omitempty" mapstructure:"auth"`
+	RateLimit      *RateLimitConfig      `json:"rate_limit,omitempty" yaml:"rateLimit,omitempty" mapstructure:"rateLimit"`
+	Cache          *CacheConfig          `json:"cache,omitempty" yaml:"cache,omitempty" mapstructure:"cache"`
+	ExtFields      *ExtFieldsConfig      `json:"ext_fields,omitempty" yaml:"extFields,omitempty" mapstructure:"extFields"`
+	Webhook        *WebhookConfig        `json:"webhook,omitempty" yaml:"webhook,omitempty" mapstructure:"webhook"`
+	Events         *EventsConfig         `json:"events,omitempty" yaml:"events,omitempty" mapstructure:"events"`
+	GraphQL        *GraphQLConfig        `json:"graphql,omitempty" yaml:"graphql,omitempty" mapstructure:"graphql"`
+	Metrics        *metrics.Config       `json:"metrics,omitempty" yaml:"metrics,omitempty" mapstructure:"metrics"`
 }
 
 // ResponseFieldsConfig 响应字段配置
@@ -47,10 +59,12 @@ func (g *Config) Validate() []error {
 
 func NewDefaultConfig() *Config {
 	return &Config{
-		Port:     3000,
-		SourceDB: db.NewDefaultDBConfig(),
-		TargetDB: db.NewDefaultDBConfig(),
-		Nats:     nsc.NewDefaultNatsConfig(),
+		Port:        3000,
+		SourceDB:    db.NewDefaultDBConfig(),
+		TargetDB:    db.NewDefaultDBConfig(),
+		Nats:        nsc.NewDefaultNatsConfig(),
+		SearchIndex: search.NewDefaultConfig(),
+		Metrics:     metrics.NewDefaultConfig(),
 	}
 }
 func TryLoadFromDisk(configFilePath string) (*Config, error) {
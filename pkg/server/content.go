@@ -0,0 +1,124 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxExcerptLength excerpt 参数允许的最大摘要长度（字符）
+const maxExcerptLength = 1000
+
+// relativeUrlAttrRe 正文中以 / 开头的 src、href 地址，如 src="/_upload/..."
+var relativeUrlAttrRe = regexp.MustCompile(`(?i)(\s(?:src|href)\s*=\s*["']?)(/[^"'\s>]*)`)
+
+// contentOptions 文章正文的输出方式，由请求参数 contentFormat、absoluteUrls、excerpt 决定
+type contentOptions struct {
+	text     bool              // 正文转换为纯文本
+	absolute bool              // 正文、封面图、附件中的站内地址补全为站点域名下的绝对地址
+	excerpt  int               // summary 为空时从正文生成的摘要长度，0 表示不生成
+	domains  map[string]string // 单次请求内 siteId -> 域名的缓存
+}
+
+// parseContentOptions 解析正文输出参数，参数值非法时返回错误
+func parseContentOptions(c *gin.Context) (*contentOptions, error) {
+	o := &contentOptions{domains: make(map[string]string)}
+	switch format := strings.ToLower(util.GetParam(c, "contentFormat")); format {
+	case "", "html":
+	case "text":
+		o.text = true
+	default:
+		return nil, fmt.Errorf("不支持的 contentFormat: %s", format)
+	}
+	if s := util.GetParam(c, "absoluteUrls"); s != "" {
+		absolute, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("absoluteUrls 必须为 true 或 false: %s", s)
+		}
+		o.absolute = absolute
+	}
+	if s := util.GetParam(c, "excerpt"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > maxExcerptLength {
+			return nil, fmt.Errorf("excerpt 必须为 0-%d 的整数: %s", maxExcerptLength, s)
+		}
+		o.excerpt = n
+	}
+	return o, nil
+}
+
+// siteDomain 站点域名，同一请求内只查询一次
+func (o *contentOptions) siteDomain(siteId string) string {
+	if domain, ok := o.domains[siteId]; ok {
+		return domain
+	}
+	var domain string
+	if id, err := strconv.Atoi(siteId); err == nil {
+		domain = getSiteDomainName(id)
+	}
+	o.domains[siteId] = domain
+	return domain
+}
+
+// apply 按输出方式改写文章的正文、摘要、封面图和附件地址
+func (o *contentOptions) apply(siteId string, content, summary, firstImgPath *string, attachments []models.Attachment) []models.Attachment {
+	if o == nil {
+		return attachments
+	}
+	if o.excerpt > 0 && strings.TrimSpace(*summary) == "" {
+		*summary = excerptOf(*content, o.excerpt)
+	}
+	if o.absolute {
+		if domain := o.siteDomain(siteId); domain != "" {
+			*content = absolutizeHTML(domain, *content)
+			*firstImgPath = absoluteUrl(domain, *firstImgPath)
+			rewritten := make([]models.Attachment, len(attachments))
+			for i, att := range attachments {
+				att.Path = absoluteUrl(domain, att.Path)
+				rewritten[i] = att
+			}
+			attachments = rewritten
+		}
+	}
+	if o.text {
+		*content = util.HTMLToText(*content)
+	}
+	return attachments
+}
+
+// applyArticle 改写列表接口中的文章
+func (o *contentOptions) applyArticle(a *models.ArticleInfo) {
+	a.Attachment = o.apply(a.SiteId, &a.Content, &a.Summary, &a.FirstImgPath, a.Attachment)
+}
+
+// applyDetail 改写文章详情
+func (o *contentOptions) applyDetail(d *ArticleDetail) {
+	d.Attachment = o.apply(d.SiteId, &d.Content, &d.Summary, &d.FirstImgPath, d.Attachment)
+}
+
+// absolutizeHTML 将正文中以 / 开头的 src、href 补全为 http://域名/路径
+func absolutizeHTML(domain, content string) string {
+	return relativeUrlAttrRe.ReplaceAllStringFunc(content, func(m string) string {
+		parts := relativeUrlAttrRe.FindStringSubmatch(m)
+		// 协议相对地址（//host/path）保持不变
+		if strings.HasPrefix(parts[2], "//") {
+			return m
+		}
+		return parts[1] + absoluteUrl(domain, parts[2])
+	})
+}
+
+// excerptOf 取正文纯文本的前 n 个字符作为摘要，截断时追加省略号
+func excerptOf(content string, n int) string {
+	text := strings.Join(strings.Fields(util.HTMLToText(content)), " ")
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n]) + "…"
+}
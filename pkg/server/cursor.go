@@ -0,0 +1,224 @@
+package server
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// articleSortFields 允许排序的字段（小写的请求参数 -> article_static 列名），isTime 表示时间类型
+var articleSortFields = map[string]struct {
+	column string
+	isTime bool
+}{
+	"publishtime":    {column: "publishTime", isTime: true},
+	"lastmodifytime": {column: "lastModifyTime", isTime: true},
+	"visitcount":     {column: "visitCount"},
+	"title":          {column: "title"},
+	"createtime":     {column: "createTime"},
+}
+
+// articleSort 文章列表排序方式，articleId 作为同方向的稳定次序
+type articleSort struct {
+	Column   string // article_static 列名，按扩展字段排序时为关联出的排序值列
+	Desc     bool
+	isTime   bool
+	extField string // 按声明了类型的扩展字段排序时的 fieldN
+	extType  string
+}
+
+// parseArticleSort 解析 sortBy/order 参数，默认 publishTime DESC；sortBy 也可以是声明了类型的扩展字段（fieldN 或语义名称）
+func parseArticleSort(sortBy, order string, ext *extFieldMapper) (articleSort, error) {
+	if sortBy == "" {
+		sortBy = "publishTime"
+	}
+	var s articleSort
+	if f, ok := articleSortFields[strings.ToLower(sortBy)]; ok {
+		s = articleSort{Column: f.column, isTime: f.isTime}
+	} else if field, ok := ext.resolveTyped(sortBy); ok {
+		t, _ := ext.typeOf(field)
+		s = articleSort{extField: field, extType: t.Type}
+		switch t.Type {
+		case extFieldTypeInt:
+			s.Column = extSortNumColumn
+		case extFieldTypeDate:
+			s.Column, s.isTime = extSortTimeColumn, true
+		default:
+			s.Column = extSortStrColumn
+		}
+	} else {
+		return articleSort{}, fmt.Errorf("不支持的 sortBy: %s", sortBy)
+	}
+	s.Desc = true
+	switch strings.ToLower(order) {
+	case "", "desc":
+	case "asc":
+		s.Desc = false
+	default:
+		return articleSort{}, fmt.Errorf("不支持的 order: %s", order)
+	}
+	return s, nil
+}
+
+// orderBy 返回 SQL 排序子句
+func (s articleSort) orderBy() string {
+	if s.Desc {
+		return s.Column + " DESC, articleId DESC"
+	}
+	return s.Column + " ASC, articleId ASC"
+}
+
+// key 游标中记录的排序字段，扩展字段为 fieldN
+func (s articleSort) key() string {
+	if s.extField != "" {
+		return s.extField
+	}
+	return s.Column
+}
+
+// orderName 返回 asc/desc
+func (s articleSort) orderName() string {
+	if s.Desc {
+		return "desc"
+	}
+	return "asc"
+}
+
+// value 取一行查询结果中排序字段的值，时间类型为毫秒时间戳，nil 表示 NULL
+func (s articleSort) value(r articleRow) *string {
+	var v string
+	switch s.Column {
+	case "publishTime", "lastModifyTime":
+		t := r.PublishTime
+		if s.Column == "lastModifyTime" {
+			t = r.LastModifyTime
+		}
+		if t == nil {
+			return nil
+		}
+		v = strconv.FormatInt(t.UnixMilli(), 10)
+	case "visitCount":
+		v = strconv.Itoa(r.VisitCount)
+	case "title":
+		v = r.Title
+	case "createTime":
+		v = r.CreateTime
+	case extSortStrColumn:
+		if r.ExtSortStr == nil {
+			return nil
+		}
+		v = *r.ExtSortStr
+	case extSortNumColumn:
+		if r.ExtSortNum == nil {
+			return nil
+		}
+		v = strconv.FormatInt(*r.ExtSortNum, 10)
+	case extSortTimeColumn:
+		if r.ExtSortTime == nil {
+			return nil
+		}
+		v = strconv.FormatInt(r.ExtSortTime.UnixMilli(), 10)
+	}
+	return &v
+}
+
+// less 判断 a 是否排在 b 之前，与 orderBy 的结果一致：NULL 视为最小值，相同时按 articleId
+func (s articleSort) less(a, b articleRow) bool {
+	va, vb := s.value(a), s.value(b)
+	c := 0
+	switch {
+	case va == nil && vb == nil:
+	case va == nil:
+		c = -1
+	case vb == nil:
+		c = 1
+	case s.isTime || s.Column == "visitCount" || s.Column == extSortNumColumn:
+		na, _ := strconv.ParseInt(*va, 10, 64)
+		nb, _ := strconv.ParseInt(*vb, 10, 64)
+		c = cmp.Compare(na, nb)
+	default:
+		c = strings.Compare(*va, *vb)
+	}
+	if c == 0 {
+		c = cmp.Compare(a.ArticleId, b.ArticleId)
+	}
+	if s.Desc {
+		return c > 0
+	}
+	return c < 0
+}
+
+// articleCursor 游标分页位置，记录排序方式以及上一页最后一篇文章的 (排序字段值, articleId)
+type articleCursor struct {
+	SortBy    string  `json:"s"`           // 排序列名，扩展字段为 fieldN
+	Order     string  `json:"o"`           // asc/desc
+	Value     *string `json:"v,omitempty"` // 排序字段值（时间为毫秒时间戳），为空表示 NULL
+	ArticleId int64   `json:"id"`          // 文章ID
+}
+
+// newArticleCursor 根据一行查询结果生成游标
+func newArticleCursor(s articleSort, r articleRow) articleCursor {
+	return articleCursor{
+		SortBy:    s.key(),
+		Order:     s.orderName(),
+		Value:     s.value(r),
+		ArticleId: r.ArticleId,
+	}
+}
+
+// encodeArticleCursor 将游标编码为对调用方不透明的字符串
+func encodeArticleCursor(cur articleCursor) string {
+	b, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeArticleCursor 解析 encodeArticleCursor 生成的游标
+func decodeArticleCursor(s string) (*articleCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %s", s)
+	}
+	var cur articleCursor
+	if err := json.Unmarshal(b, &cur); err != nil || cur.ArticleId <= 0 {
+		return nil, fmt.Errorf("invalid cursor: %s", s)
+	}
+	return &cur, nil
+}
+
+// whereAfter 返回按 s 排序时位于游标之后的查询条件，游标与排序方式不一致时返回错误
+// MySQL 中 NULL 视为最小值：降序时排在最后，升序时排在最前
+func (cur *articleCursor) whereAfter(s articleSort, loc *time.Location) (string, []interface{}, error) {
+	if cur.SortBy != s.key() || cur.Order != s.orderName() {
+		return "", nil, fmt.Errorf("cursor 与排序参数不一致，请去掉 cursor 重新从首页开始")
+	}
+	col := s.Column
+	if cur.Value == nil {
+		if s.Desc {
+			return col + " IS NULL AND articleId < ?", []interface{}{cur.ArticleId}, nil
+		}
+		return "((" + col + " IS NULL AND articleId > ?) OR " + col + " IS NOT NULL)", []interface{}{cur.ArticleId}, nil
+	}
+
+	var v interface{} = *cur.Value
+	if s.isTime {
+		ms, err := strconv.ParseInt(*cur.Value, 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid cursor: %v", err)
+		}
+		v = time.UnixMilli(ms).In(loc)
+	} else if col == "visitCount" || col == extSortNumColumn {
+		n, err := strconv.ParseInt(*cur.Value, 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid cursor: %v", err)
+		}
+		v = n
+	}
+	if s.Desc {
+		return "(" + col + " < ? OR (" + col + " = ? AND articleId < ?) OR " + col + " IS NULL)", []interface{}{v, v, cur.ArticleId}, nil
+	}
+	return "(" + col + " > ? OR (" + col + " = ? AND articleId > ?))", []interface{}{v, v, cur.ArticleId}, nil
+}
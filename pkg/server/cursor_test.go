@@ -0,0 +1,192 @@
+package server
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestWhereAfter(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	publishDesc := articleSort{Column: "publishTime", Desc: true, isTime: true}
+	publishAsc := articleSort{Column: "publishTime", isTime: true}
+	visitDesc := articleSort{Column: "visitCount", Desc: true}
+	titleAsc := articleSort{Column: "title"}
+	ms := time.Date(2025, 3, 1, 8, 0, 0, 0, loc).UnixMilli()
+
+	tests := []struct {
+		name      string
+		sort      articleSort
+		cur       articleCursor
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "desc value, NULLs come last",
+			sort:      publishDesc,
+			cur:       articleCursor{SortBy: "publishTime", Order: "desc", Value: strPtr("1740787200000"), ArticleId: 9},
+			wantWhere: "(publishTime < ? OR (publishTime = ? AND articleId < ?) OR publishTime IS NULL)",
+			wantArgs:  []interface{}{time.UnixMilli(ms).In(loc), time.UnixMilli(ms).In(loc), int64(9)},
+		},
+		{
+			name:      "asc value, NULLs already passed",
+			sort:      publishAsc,
+			cur:       articleCursor{SortBy: "publishTime", Order: "asc", Value: strPtr("1740787200000"), ArticleId: 9},
+			wantWhere: "(publishTime > ? OR (publishTime = ? AND articleId > ?))",
+			wantArgs:  []interface{}{time.UnixMilli(ms).In(loc), time.UnixMilli(ms).In(loc), int64(9)},
+		},
+		{
+			name:      "desc NULL, only smaller ids among NULLs remain",
+			sort:      publishDesc,
+			cur:       articleCursor{SortBy: "publishTime", Order: "desc", ArticleId: 9},
+			wantWhere: "publishTime IS NULL AND articleId < ?",
+			wantArgs:  []interface{}{int64(9)},
+		},
+		{
+			name:      "asc NULL, larger ids among NULLs then all values",
+			sort:      publishAsc,
+			cur:       articleCursor{SortBy: "publishTime", Order: "asc", ArticleId: 9},
+			wantWhere: "((publishTime IS NULL AND articleId > ?) OR publishTime IS NOT NULL)",
+			wantArgs:  []interface{}{int64(9)},
+		},
+		{
+			name:      "numeric value is compared as integer",
+			sort:      visitDesc,
+			cur:       articleCursor{SortBy: "visitCount", Order: "desc", Value: strPtr("10"), ArticleId: 3},
+			wantWhere: "(visitCount < ? OR (visitCount = ? AND articleId < ?) OR visitCount IS NULL)",
+			wantArgs:  []interface{}{int64(10), int64(10), int64(3)},
+		},
+		{
+			name:      "string value is compared as string",
+			sort:      titleAsc,
+			cur:       articleCursor{SortBy: "title", Order: "asc", Value: strPtr("10"), ArticleId: 3},
+			wantWhere: "(title > ? OR (title = ? AND articleId > ?))",
+			wantArgs:  []interface{}{"10", "10", int64(3)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args, err := tt.cur.whereAfter(tt.sort, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if where != tt.wantWhere {
+				t.Errorf("where = %s\nwant    %s", where, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestWhereAfterErrors(t *testing.T) {
+	publishDesc := articleSort{Column: "publishTime", Desc: true, isTime: true}
+	tests := []struct {
+		name    string
+		sort    articleSort
+		cur     articleCursor
+		wantErr string
+	}{
+		{
+			name:    "sort column mismatch",
+			sort:    publishDesc,
+			cur:     articleCursor{SortBy: "visitCount", Order: "desc", Value: strPtr("1"), ArticleId: 1},
+			wantErr: "cursor 与排序参数不一致",
+		},
+		{
+			name:    "order mismatch",
+			sort:    publishDesc,
+			cur:     articleCursor{SortBy: "publishTime", Order: "asc", Value: strPtr("1"), ArticleId: 1},
+			wantErr: "cursor 与排序参数不一致",
+		},
+		{
+			name:    "extension field cursor on builtin sort",
+			sort:    articleSort{Column: extSortNumColumn, Desc: true, extField: "field3", extType: extFieldTypeInt},
+			cur:     articleCursor{SortBy: "field4", Order: "desc", Value: strPtr("1"), ArticleId: 1},
+			wantErr: "cursor 与排序参数不一致",
+		},
+		{
+			name:    "bad time value",
+			sort:    publishDesc,
+			cur:     articleCursor{SortBy: "publishTime", Order: "desc", Value: strPtr("yesterday"), ArticleId: 1},
+			wantErr: "invalid cursor",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := tt.cur.whereAfter(tt.sort, time.UTC)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestArticleCursorRoundTrip(t *testing.T) {
+	s := articleSort{Column: "publishTime", Desc: true, isTime: true}
+	published := time.UnixMilli(1740787200123)
+	for _, row := range []articleRow{
+		{ArticleId: 42, PublishTime: &published},
+		{ArticleId: 43},
+	} {
+		cur := newArticleCursor(s, row)
+		got, err := decodeArticleCursor(encodeArticleCursor(cur))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(*got, cur) {
+			t.Fatalf("decoded %#v, want %#v", *got, cur)
+		}
+	}
+}
+
+func TestDecodeArticleCursorInvalid(t *testing.T) {
+	for _, s := range []string{
+		"not base64!",
+		"bm90IGpzb24", // "not json"
+		encodeArticleCursor(articleCursor{SortBy: "publishTime", Order: "desc"}), // 缺少 articleId
+	} {
+		if _, err := decodeArticleCursor(s); err == nil {
+			t.Errorf("decodeArticleCursor(%q) should fail", s)
+		}
+	}
+}
+
+func TestArticleSortLess(t *testing.T) {
+	t1 := time.UnixMilli(1000)
+	t2 := time.UnixMilli(2000)
+	rows := []articleRow{
+		{ArticleId: 1, PublishTime: &t2},
+		{ArticleId: 2},
+		{ArticleId: 3, PublishTime: &t1},
+		{ArticleId: 4, PublishTime: &t2},
+		{ArticleId: 5},
+	}
+	ids := func(s articleSort) []int64 {
+		sorted := append([]articleRow(nil), rows...)
+		sort.Slice(sorted, func(i, j int) bool { return s.less(sorted[i], sorted[j]) })
+		out := make([]int64, len(sorted))
+		for i, r := range sorted {
+			out[i] = r.ArticleId
+		}
+		return out
+	}
+	// 与 MySQL 的 ORDER BY publishTime DESC, articleId DESC 一致：NULL 排在最后
+	if got, want := ids(articleSort{Column: "publishTime", Desc: true, isTime: true}), []int64{4, 1, 3, 5, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("desc = %v, want %v", got, want)
+	}
+	// ORDER BY publishTime ASC, articleId ASC：NULL 排在最前
+	if got, want := ids(articleSort{Column: "publishTime", isTime: true}), []int64{2, 5, 3, 1, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("asc = %v, want %v", got, want)
+	}
+	// 数值按大小而不是字符串比较
+	a, b := articleRow{ArticleId: 1, VisitCount: 9}, articleRow{ArticleId: 2, VisitCount: 10}
+	if !(articleSort{Column: "visitCount"}).less(a, b) {
+		t.Error("visitCount 9 should sort before 10")
+	}
+}
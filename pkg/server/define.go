@@ -1,11 +1,22 @@
 package server
 
-import "gorm.io/gorm"
+import (
+	"time"
+	"webplus-openapi/pkg/models"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"gorm.io/gorm"
+)
 
 // Handler v1版本API处理器
 type Handler struct {
-	cfg Config
-	db  *gorm.DB // 来自 targetDB 的只读 MySQL
+	cfg   Config
+	db    *gorm.DB      // 来自 targetDB 的只读 MySQL
+	usage *usageTracker // 调用量统计
+	// extFields 扩展字段语义名称映射，未配置时为 nil
+	extFields *extFieldMapper
+	// graphql /graphql 的 Schema
+	graphql *graphql.Schema
 }
 
 // ColumnInfo 栏目信息响应结构体
@@ -49,3 +60,35 @@ type GetSitesResponse struct {
 	Items      []SiteInfo           `json:"items"`      // 站点列表
 	Pagination GetColumnsPagination `json:"pagination"` // 分页信息（复用栏目分页结构）
 }
+
+// ArticleColumnInfo 文章所属栏目信息
+type ArticleColumnInfo struct {
+	ColumnId   string `json:"columnId"`   // 栏目ID
+	ColumnName string `json:"columnName"` // 栏目名称
+	SiteId     string `json:"siteId"`     // 站点ID
+	SiteName   string `json:"siteName"`   // 站点名称
+	Url        string `json:"url"`        // 文章在该栏目下的访问地址
+}
+
+// ArticleDetail GetArticle API 响应结构体
+type ArticleDetail struct {
+	ArticleId      string              `json:"articleId"`      // 文章ID
+	SiteId         string              `json:"siteId"`         // 创建站点ID
+	Title          string              `json:"title"`          // 文章标题
+	ShortTitle     string              `json:"shortTitle"`     // 文章短标题
+	AuxiliaryTitle string              `json:"auxiliaryTitle"` // 文章副标题
+	CreatorName    string              `json:"creatorName"`    // 作者
+	Summary        string              `json:"summary"`        // 文章简介
+	PublishTime    *time.Time          `json:"publishTime"`    // 发布时间
+	LastModifyTime *time.Time          `json:"lastModifyTime"` // 最后修改时间
+	PublisherName  string              `json:"publisherName"`  // 发布人名称
+	PublishOrgName string              `json:"publishOrgName"` // 发布单位名称
+	FirstImgPath   string              `json:"firstImgPath"`   // 封面图地址
+	VisitUrl       string              `json:"visitUrl"`       // 访问地址
+	VisitCount     int                 `json:"visitCount"`     // 访问量
+	Keywords       string              `json:"keywords"`       // 关键字
+	Content        string              `json:"content"`        // 文章内容
+	Attachment     []models.Attachment `json:"attachment"`     // 附件
+	ColumnInfo     []ArticleColumnInfo `json:"columnInfo"`     // 所属栏目
+	models.ArticleFields
+}
@@ -0,0 +1,266 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// EventsConfig /events 实时推送配置
+type EventsConfig struct {
+	// Heartbeat 心跳间隔（秒），默认 15
+	Heartbeat int `json:"heartbeat,omitempty" yaml:"heartbeat,omitempty" mapstructure:"heartbeat"`
+	// BufferSize 每个连接最多缓存的未发送事件数，超出时断开该连接，客户端带 Last-Event-ID 重连后补发，默认 256
+	BufferSize int `json:"buffer_size,omitempty" yaml:"bufferSize,omitempty" mapstructure:"bufferSize"`
+	// MaxClients 最大连接数，0 表示不限制
+	MaxClients int `json:"max_clients,omitempty" yaml:"maxClients,omitempty" mapstructure:"maxClients"`
+}
+
+// eventReplayPageSize 按 Last-Event-ID 补发时每次从变更日志读取的条数
+const eventReplayPageSize = 500
+
+// eventSubscriber 一个 /events 连接；缓冲区满时由 broker 关闭 ch，连接随之结束
+type eventSubscriber struct {
+	ch    chan models.ArticleChangeLog
+	match func(change *models.ArticleChangeLog) bool
+}
+
+// eventBroker 将 NATS 处理后的文章变更分发给 /events 连接；发送不阻塞，慢连接被断开而不是拖慢 NATS 处理
+type eventBroker struct {
+	mu         sync.Mutex
+	subs       map[*eventSubscriber]struct{}
+	heartbeat  time.Duration
+	bufferSize int
+	maxClients int
+}
+
+var (
+	events     *eventBroker
+	eventsOnce sync.Once
+)
+
+// initEventBroker 按配置创建进程内唯一的 broker
+func initEventBroker(cfg *EventsConfig) *eventBroker {
+	eventsOnce.Do(func() {
+		b := &eventBroker{
+			subs:       make(map[*eventSubscriber]struct{}),
+			heartbeat:  15 * time.Second,
+			bufferSize: 256,
+		}
+		if cfg != nil {
+			if cfg.Heartbeat > 0 {
+				b.heartbeat = time.Duration(cfg.Heartbeat) * time.Second
+			}
+			if cfg.BufferSize > 0 {
+				b.bufferSize = cfg.BufferSize
+			}
+			b.maxClients = cfg.MaxClients
+		}
+		events = b
+	})
+	return events
+}
+
+// subscribe 注册连接，超过最大连接数时返回 false
+func (b *eventBroker) subscribe(match func(change *models.ArticleChangeLog) bool) (*eventSubscriber, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.maxClients > 0 && len(b.subs) >= b.maxClients {
+		return nil, false
+	}
+	s := &eventSubscriber{ch: make(chan models.ArticleChangeLog, b.bufferSize), match: match}
+	b.subs[s] = struct{}{}
+	return s, true
+}
+
+// unsubscribe 注销连接，已被 publish 断开时不重复关闭
+func (b *eventBroker) unsubscribe(s *eventSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.ch)
+	}
+}
+
+// publish 把变更发送给匹配的连接，缓冲区已满的连接直接断开
+func (b *eventBroker) publish(change *models.ArticleChangeLog) {
+	if b == nil || change == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs {
+		if !s.match(change) {
+			continue
+		}
+		select {
+		case s.ch <- *change:
+		default:
+			delete(b.subs, s)
+			close(s.ch)
+			zap.S().Warnf("/events 连接处理过慢，已断开: seq=%d", change.Seq)
+		}
+	}
+}
+
+// eventName SSE 事件类型
+func eventName(change *models.ArticleChangeLog) string {
+	switch change.Op {
+	case models.ArticleChangeUpsert:
+		if change.Created {
+			return "article.created"
+		}
+		return "article.updated"
+	case models.ArticleChangeDelete:
+		return "article.deleted"
+	case models.ArticleChangeColumnAdd:
+		return "column.added"
+	case models.ArticleChangeColumnRemove:
+		return "column.removed"
+	}
+	return change.Op
+}
+
+// writeEvent 写入一个 SSE 事件，id 为变更日志 seq
+func writeEvent(c *gin.Context, change *models.ArticleChangeLog) error {
+	data, err := json.Marshal(newChangeEvent(*change))
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", change.Seq, eventName(change), data); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// eventMatcher 按调用方范围和请求中的 siteId、columnId 过滤变更
+func eventMatcher(cl *apiClient, siteIds, columnIds []string) func(change *models.ArticleChangeLog) bool {
+	var scopeSites, scopeColumns []string
+	if cl.restricted() {
+		scopeSites = cl.SiteIds
+		for _, id := range cl.ColumnIds {
+			scopeColumns = append(scopeColumns, strconv.FormatInt(id, 10))
+		}
+	}
+	return func(change *models.ArticleChangeLog) bool {
+		return changeMatches(scopeSites, scopeColumns, nil, change) && changeMatches(siteIds, columnIds, nil, change)
+	}
+}
+
+// GetEvents 文章变更实时推送
+// @Summary      文章变更实时推送（SSE）
+// @Description  text/event-stream 推送 NATS 同步的文章变更，事件类型为 article.created、article.updated、article.deleted、column.added、column.removed，data 与 /changes 的事件相同，id 为 seq。
+// @Description  断线重连时浏览器自动携带 Last-Event-ID（也可以传 lastEventId 参数），从变更日志补发之后的事件；连接处理过慢时服务端主动断开，重连后同样补发。空闲时定期发送 ": ping" 心跳
+// @Tags         articles
+// @Produce      text/event-stream
+// @Param        siteId       query   string  false  "站点ID，逗号分隔，与 columnId 取并集"
+// @Param        columnId     query   string  false  "栏目ID，逗号分隔"
+// @Param        lastEventId  query   int     false  "从该 seq 之后开始补发，Last-Event-ID 请求头优先"
+// @Param        Last-Event-ID header int     false  "从该 seq 之后开始补发"
+// @Success      200  {string}  string  "事件流"
+// @Failure      400  {object}  util.Response
+// @Failure      503  {object}  util.Response
+// @Router       /api/v1/webplus/events [get]
+func (h *Handler) GetEvents(c *gin.Context) {
+	var (
+		lastId int64
+		resume bool
+	)
+	lastIdStr := strings.TrimSpace(c.GetHeader("Last-Event-ID"))
+	if lastIdStr == "" {
+		lastIdStr = util.GetParam(c, "lastEventId")
+	}
+	if lastIdStr != "" {
+		v, err := strconv.ParseInt(lastIdStr, 10, 64)
+		if err != nil || v < 0 {
+			util.Err(c, gin.H{"error": fmt.Sprintf("Last-Event-ID 必须为非负整数: %s", lastIdStr), "code": http.StatusBadRequest})
+			return
+		}
+		lastId, resume = v, true
+	}
+	siteIds := splitIds(util.GetParam(c, "siteId"))
+	columnIds := splitIds(util.GetParam(c, "columnId"))
+	targetDB := db.GetTargetDB()
+	if events == nil || (resume && targetDB == nil) {
+		util.Err(c, gin.H{"error": "实时推送不可用", "code": http.StatusServiceUnavailable})
+		return
+	}
+
+	// 先注册再补发，补发期间的新变更在缓冲区中等待，之后按 seq 去重
+	cl := currentClient(c)
+	match := eventMatcher(cl, siteIds, columnIds)
+	sub, ok := events.subscribe(match)
+	if !ok {
+		util.Err(c, gin.H{"error": "实时推送连接数已满", "code": http.StatusServiceUnavailable})
+		return
+	}
+	defer events.unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	_, _ = fmt.Fprintf(c.Writer, "retry: 3000\n\n")
+	c.Writer.Flush()
+
+	if resume {
+		for {
+			logs, err := queryArticleChanges(targetDB, cl, lastId, eventReplayPageSize)
+			if err != nil {
+				zap.S().Warnf("/events 补发失败: lastEventId=%d, err=%v", lastId, err)
+				return
+			}
+			for i := range logs {
+				lastId = logs[i].Seq
+				if !match(&logs[i]) {
+					continue
+				}
+				if err := writeEvent(c, &logs[i]); err != nil {
+					return
+				}
+			}
+			if len(logs) < eventReplayPageSize {
+				break
+			}
+		}
+	}
+
+	ctx := c.Request.Context()
+	heartbeat := time.NewTicker(events.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case change, ok := <-sub.ch:
+			if !ok {
+				// 缓冲区已满被断开，客户端重连后从 Last-Event-ID 补发
+				return
+			}
+			if change.Seq <= lastId {
+				continue
+			}
+			if err := writeEvent(c, &change); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"webplus-openapi/docs"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+)
+
+// ExtFieldsConfig 扩展字段 field1-field50 的语义名称配置，站点配置优先于全局配置
+type ExtFieldsConfig struct {
+	// Global 所有站点通用的映射，键为 fieldN
+	Global map[string]ExtFieldConfig `json:"global,omitempty" yaml:"global,omitempty" mapstructure:"global"`
+	// Sites 按站点ID覆盖的映射
+	Sites map[string]map[string]ExtFieldConfig `json:"sites,omitempty" yaml:"sites,omitempty" mapstructure:"sites"`
+	// Types 扩展字段类型，键为 fieldN；声明类型的字段支持精确匹配、IN、范围过滤和排序
+	Types map[string]ExtFieldTypeConfig `json:"types,omitempty" yaml:"types,omitempty" mapstructure:"types"`
+}
+
+// ExtFieldConfig 单个扩展字段的语义名称
+type ExtFieldConfig struct {
+	Name  string `json:"name" yaml:"name" mapstructure:"name"`    // 响应中使用的 JSON 键，如 eventLocation
+	Label string `json:"label" yaml:"label" mapstructure:"label"` // 显示名称，如 活动地点
+}
+
+// ExtFieldTypeConfig 扩展字段类型
+type ExtFieldTypeConfig struct {
+	Type   string   `json:"type" yaml:"type" mapstructure:"type"`                           // string、int、date、enum
+	Values []string `json:"values,omitempty" yaml:"values,omitempty" mapstructure:"values"` // enum 的可选值
+}
+
+// ExtFieldDefinition fieldDefinitions 接口返回的字段定义
+type ExtFieldDefinition struct {
+	Field  string   `json:"field"`            // 原字段名，如 field3
+	Name   string   `json:"name"`             // 语义名称，未配置时为 fieldN
+	Label  string   `json:"label"`            // 显示名称
+	Type   string   `json:"type,omitempty"`   // 字段类型，未声明时为空
+	Values []string `json:"values,omitempty"` // enum 的可选值
+}
+
+// extFieldMapper 解析后的扩展字段映射，nil 表示未配置
+type extFieldMapper struct {
+	global map[string]ExtFieldConfig
+	sites  map[string]map[string]ExtFieldConfig
+	// names 语义名称（小写） -> 可能对应的 fieldN，不同站点可能不同
+	names map[string][]string
+	types map[string]ExtFieldTypeConfig
+}
+
+// newExtFieldMapper 校验并整理配置，字段名非法或语义名称与固定字段冲突的项忽略
+func newExtFieldMapper(cfg *ExtFieldsConfig) *extFieldMapper {
+	if cfg == nil || (len(cfg.Global) == 0 && len(cfg.Sites) == 0 && len(cfg.Types) == 0) {
+		return nil
+	}
+	m := &extFieldMapper{
+		global: make(map[string]ExtFieldConfig),
+		sites:  make(map[string]map[string]ExtFieldConfig),
+		names:  make(map[string][]string),
+		types:  make(map[string]ExtFieldTypeConfig),
+	}
+	clean := func(scope string, src map[string]ExtFieldConfig) map[string]ExtFieldConfig {
+		dst := make(map[string]ExtFieldConfig, len(src))
+		for field, def := range src {
+			field = strings.ToLower(strings.TrimSpace(field))
+			def.Name = strings.TrimSpace(def.Name)
+			if extFieldIndex(field) == 0 || def.Name == "" {
+				zap.S().Warnf("扩展字段配置无效（%s）: %s -> %q", scope, field, def.Name)
+				continue
+			}
+			if col, ok := articleFieldColumns[strings.ToLower(def.Name)]; ok && col != field {
+				zap.S().Warnf("扩展字段 %s 的名称 %s 与已有字段冲突（%s），已忽略", field, def.Name, scope)
+				continue
+			}
+			if def.Label == "" {
+				def.Label = def.Name
+			}
+			dst[field] = def
+			key := strings.ToLower(def.Name)
+			if !lo.Contains(m.names[key], field) {
+				m.names[key] = append(m.names[key], field)
+			}
+		}
+		return dst
+	}
+	m.global = clean("global", cfg.Global)
+	for siteId, fields := range cfg.Sites {
+		m.sites[strings.TrimSpace(siteId)] = clean("site "+siteId, fields)
+	}
+	for field, t := range cfg.Types {
+		field = strings.ToLower(strings.TrimSpace(field))
+		t.Type = strings.ToLower(strings.TrimSpace(t.Type))
+		if extFieldIndex(field) == 0 || !lo.Contains(extFieldTypes, t.Type) {
+			zap.S().Warnf("扩展字段类型配置无效: %s -> %q", field, t.Type)
+			continue
+		}
+		if t.Type == extFieldTypeEnum && len(t.Values) == 0 {
+			zap.S().Warnf("扩展字段 %s 为 enum 类型但未配置 values，已忽略", field)
+			continue
+		}
+		m.types[field] = t
+	}
+	zap.S().Infof("扩展字段映射已加载，全局 %d 个，站点 %d 个，类型 %d 个", len(m.global), len(m.sites), len(m.types))
+	return m
+}
+
+// extFieldIndex 返回 fieldN 中的 N，不是合法扩展字段时返回 0
+func extFieldIndex(field string) int {
+	if !strings.HasPrefix(field, "field") {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(field, "field"))
+	if err != nil || n < 1 || n > 50 {
+		return 0
+	}
+	return n
+}
+
+// lookup 站点下 fieldN 的语义名称，站点未配置时使用全局配置
+func (m *extFieldMapper) lookup(siteId, field string) (ExtFieldConfig, bool) {
+	if m == nil {
+		return ExtFieldConfig{}, false
+	}
+	if def, ok := m.sites[siteId][field]; ok {
+		return def, true
+	}
+	def, ok := m.global[field]
+	return def, ok
+}
+
+// namesOf fieldN 在全局和各站点配置中的全部语义名称，全局名称在前
+func (m *extFieldMapper) namesOf(field string) []string {
+	if m == nil {
+		return nil
+	}
+	var names []string
+	if def, ok := m.global[field]; ok {
+		names = append(names, def.Name)
+	}
+	siteIds := make([]string, 0, len(m.sites))
+	for siteId := range m.sites {
+		siteIds = append(siteIds, siteId)
+	}
+	sort.Strings(siteIds)
+	for _, siteId := range siteIds {
+		if def, ok := m.sites[siteId][field]; ok && !lo.Contains(names, def.Name) {
+			names = append(names, def.Name)
+		}
+	}
+	return names
+}
+
+// isName 判断是否为已配置的语义名称（不区分大小写）
+func (m *extFieldMapper) isName(name string) bool {
+	if m == nil {
+		return false
+	}
+	_, ok := m.names[strings.ToLower(name)]
+	return ok
+}
+
+// fieldsOf 语义名称（不区分大小写）在各站点配置中对应的 fieldN
+func (m *extFieldMapper) fieldsOf(name string) []string {
+	if m == nil {
+		return nil
+	}
+	return m.names[strings.ToLower(name)]
+}
+
+// rename 将响应中的 fieldN 键替换为站点对应的语义名称
+func (m *extFieldMapper) rename(siteId string, item gin.H) gin.H {
+	if m == nil {
+		return item
+	}
+	for key, value := range item {
+		if def, ok := m.lookup(siteId, key); ok {
+			delete(item, key)
+			item[def.Name] = value
+		}
+	}
+	return item
+}
+
+// definitions 站点生效的字段定义（站点覆盖全局），按字段序号排序；siteId 为空时只返回全局定义，只声明了类型的字段也会返回
+func (m *extFieldMapper) definitions(siteId string) []ExtFieldDefinition {
+	defs := make([]ExtFieldDefinition, 0)
+	if m == nil {
+		return defs
+	}
+	for i := 1; i <= 50; i++ {
+		field := fmt.Sprintf("field%d", i)
+		var (
+			def ExtFieldConfig
+			ok  bool
+		)
+		if siteId == "" {
+			def, ok = m.global[field]
+		} else {
+			def, ok = m.lookup(siteId, field)
+		}
+		t, typed := m.types[field]
+		if !ok && !typed {
+			continue
+		}
+		if !ok {
+			def = ExtFieldConfig{Name: field, Label: field}
+		}
+		defs = append(defs, ExtFieldDefinition{Field: field, Name: def.Name, Label: def.Label, Type: t.Type, Values: t.Values})
+	}
+	return defs
+}
+
+// GetFieldDefinitions 扩展字段定义
+// @Summary      扩展字段定义
+// @Description  返回 field1-field50 扩展字段的语义名称、显示名称和类型；传 siteId 时返回该站点生效的定义（站点配置覆盖全局配置）
+// @Tags         articles
+// @Produce      json
+// @Param        siteId  query  string  false  "站点ID"
+// @Success      200  {object}  util.Response
+// @Router       /api/v1/webplus/fieldDefinitions [get]
+func (h *Handler) GetFieldDefinitions(c *gin.Context) {
+	if siteId := strings.TrimSpace(util.GetParam(c, "siteId")); siteId != "" {
+		util.Ok(c, gin.H{
+			"siteId": siteId,
+			"items":  h.extFields.definitions(siteId),
+		})
+		return
+	}
+	sites := make(gin.H)
+	if h.extFields != nil {
+		for siteId := range h.extFields.sites {
+			sites[siteId] = h.extFields.definitions(siteId)
+		}
+	}
+	util.Ok(c, gin.H{
+		"global": h.extFields.definitions(""),
+		"sites":  sites,
+	})
+}
+
+// patchSwaggerDoc 将扩展字段映射写入运行时的 OpenAPI 文档：增加 ExtFields 定义和 getArticles 的模糊搜索、类型过滤参数
+func patchSwaggerDoc(m *extFieldMapper, searchCfg *SearchConfig) {
+	if m == nil {
+		return
+	}
+	var fuzzyFields []string
+	if searchCfg != nil {
+		fuzzyFields = searchCfg.FuzzyField
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &doc); err != nil {
+		zap.S().Warnf("解析 OpenAPI 文档失败，扩展字段未写入文档: %v", err)
+		return
+	}
+
+	props := make(map[string]interface{})
+	for i := 1; i <= 50; i++ {
+		field := fmt.Sprintf("field%d", i)
+		for _, name := range m.namesOf(field) {
+			props[name] = map[string]interface{}{
+				"type":        "string",
+				"description": fmt.Sprintf("%s（%s）", extFieldLabel(m, field, name), field),
+			}
+		}
+	}
+	definitions, _ := doc["definitions"].(map[string]interface{})
+	if definitions == nil {
+		definitions = make(map[string]interface{})
+		doc["definitions"] = definitions
+	}
+	definitions["server.ExtFields"] = map[string]interface{}{
+		"type":        "object",
+		"description": "扩展字段，响应中 fieldN 按站点配置替换为语义名称，未配置的保持 fieldN",
+		"properties":  props,
+	}
+
+	// getArticles 的模糊搜索参数同时接受语义名称
+	if paths, ok := doc["paths"].(map[string]interface{}); ok {
+		if path, ok := paths["/api/v1/webplus/getArticles"].(map[string]interface{}); ok {
+			for _, op := range path {
+				operation, ok := op.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				params, _ := operation["parameters"].([]interface{})
+				for _, field := range fuzzyFields {
+					if _, typed := m.typeOf(strings.ToLower(field)); typed {
+						continue
+					}
+					for _, name := range m.namesOf(strings.ToLower(field)) {
+						params = append(params, map[string]interface{}{
+							"type":        "string",
+							"name":        name,
+							"in":          "query",
+							"description": fmt.Sprintf("按%s模糊搜索（%s）", extFieldLabel(m, strings.ToLower(field), name), field),
+						})
+					}
+				}
+				for _, field := range m.typedFields() {
+					params = append(params, extFilterSwaggerParams(m, field)...)
+				}
+				operation["parameters"] = params
+			}
+		}
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		zap.S().Warnf("生成 OpenAPI 文档失败: %v", err)
+		return
+	}
+	docs.SwaggerInfo.SwaggerTemplate = string(b)
+}
+
+// extFilterSwaggerParams 声明了类型的扩展字段的过滤参数，参数名使用第一个语义名称（未配置时为 fieldN）
+func extFilterSwaggerParams(m *extFieldMapper, field string) []interface{} {
+	t := m.types[field]
+	name := field
+	if names := m.namesOf(field); len(names) > 0 {
+		name = names[0]
+	}
+	label := extFieldLabel(m, field, name)
+	param := func(suffix, desc string) interface{} {
+		return map[string]interface{}{
+			"type":        "string",
+			"name":        name + suffix,
+			"in":          "query",
+			"description": fmt.Sprintf("%s%s（%s，%s）", label, desc, field, t.Type),
+		}
+	}
+	params := []interface{}{param("", "精确匹配"), param("In", "匹配任一值，逗号分隔")}
+	if t.Type == extFieldTypeInt || t.Type == extFieldTypeDate {
+		params = append(params, param("From", "起始值（含）"), param("To", "结束值（含）"))
+	}
+	return params
+}
+
+// extFieldLabel 语义名称对应的显示名称
+func extFieldLabel(m *extFieldMapper, field, name string) string {
+	if def, ok := m.global[field]; ok && def.Name == name {
+		return def.Label
+	}
+	for _, fields := range m.sites {
+		if def, ok := fields[field]; ok && def.Name == name {
+			return def.Label
+		}
+	}
+	return name
+}
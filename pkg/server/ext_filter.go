@@ -0,0 +1,228 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+// 扩展字段类型
+const (
+	extFieldTypeString = "string"
+	extFieldTypeInt    = "int"
+	extFieldTypeDate   = "date"
+	extFieldTypeEnum   = "enum"
+)
+
+var extFieldTypes = []string{extFieldTypeString, extFieldTypeInt, extFieldTypeDate, extFieldTypeEnum}
+
+// 按扩展字段排序时，article_field_index 中排序值在查询结果里的列名
+const (
+	extSortStrColumn  = "extSortStr"
+	extSortNumColumn  = "extSortNum"
+	extSortTimeColumn = "extSortTime"
+)
+
+// typeOf 扩展字段声明的类型
+func (m *extFieldMapper) typeOf(field string) (ExtFieldTypeConfig, bool) {
+	if m == nil {
+		return ExtFieldTypeConfig{}, false
+	}
+	t, ok := m.types[field]
+	return t, ok
+}
+
+// typedFields 声明了类型的扩展字段，按字段序号排序
+func (m *extFieldMapper) typedFields() []string {
+	if m == nil {
+		return nil
+	}
+	fields := lo.Keys(m.types)
+	sort.Slice(fields, func(i, j int) bool { return extFieldIndex(fields[i]) < extFieldIndex(fields[j]) })
+	return fields
+}
+
+// resolveTyped 将请求中的字段名（fieldN 或语义名称，不区分大小写）解析为声明了类型的 fieldN
+func (m *extFieldMapper) resolveTyped(name string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	name = strings.ToLower(name)
+	if _, ok := m.types[name]; ok {
+		return name, true
+	}
+	for _, field := range m.names[name] {
+		if _, ok := m.types[field]; ok {
+			return field, true
+		}
+	}
+	return "", false
+}
+
+// extIndexColumn 字段类型对应的 article_field_index 列
+func extIndexColumn(fieldType string) string {
+	switch fieldType {
+	case extFieldTypeInt:
+		return "numValue"
+	case extFieldTypeDate:
+		return "timeValue"
+	default:
+		return "strValue"
+	}
+}
+
+// extFilterValue 按字段类型解析过滤值；日期只包含日期部分时 dateOnly 为 true
+func extFilterValue(param string, t ExtFieldTypeConfig, raw string) (v interface{}, dateOnly bool, err error) {
+	raw = strings.TrimSpace(raw)
+	switch t.Type {
+	case extFieldTypeInt:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s 必须为整数: %s", param, raw)
+		}
+		return n, false, nil
+	case extFieldTypeDate:
+		parsed, dateOnly, ok := models.ParseArticleFieldTime(raw)
+		if !ok {
+			return nil, false, fmt.Errorf("%s 日期格式错误: %s", param, raw)
+		}
+		return parsed, dateOnly, nil
+	case extFieldTypeEnum:
+		if !lo.Contains(t.Values, raw) {
+			return nil, false, fmt.Errorf("%s 可选值为 %s: %s", param, strings.Join(t.Values, ","), raw)
+		}
+	}
+	return raw, false, nil
+}
+
+// extParam 请求中的一个过滤参数，name 用于错误提示
+type extParam struct {
+	name  string
+	value string
+}
+
+// extFieldCond 单个扩展字段的过滤条件：精确匹配、IN、范围，空值表示不限制
+type extFieldCond struct {
+	eq, in, from, to extParam
+}
+
+// param 依次按 fieldN 和语义名称加后缀读取参数，返回第一个非空值
+func (m *extFieldMapper) param(c *gin.Context, field, suffix string) extParam {
+	for _, name := range append([]string{field}, m.namesOf(field)...) {
+		if v := util.GetParam(c, name+suffix); v != "" {
+			return extParam{name: name + suffix, value: v}
+		}
+	}
+	return extParam{}
+}
+
+// applyExtFilters 按声明了类型的扩展字段过滤文章，参数名可以是 fieldN 或语义名称：
+//
+//	fieldN=v          精确匹配，date 类型只传日期时匹配当天
+//	fieldNIn=v1,v2    匹配任一值
+//	fieldNFrom / To   范围过滤（含边界），仅 int、date 类型；date 只传日期时 To 包含当天
+func (m *extFieldMapper) applyExtFilters(c *gin.Context, targetDB, query *gorm.DB) (*gorm.DB, error) {
+	for _, field := range m.typedFields() {
+		cond := extFieldCond{
+			eq:   m.param(c, field, ""),
+			in:   m.param(c, field, "In"),
+			from: m.param(c, field, "From"),
+			to:   m.param(c, field, "To"),
+		}
+		sub, err := m.extFieldSubquery(targetDB, field, cond)
+		if err != nil {
+			return nil, err
+		}
+		if sub != nil {
+			query = query.Where("articleId IN (?)", sub)
+		}
+	}
+	return query, nil
+}
+
+// extFieldSubquery 生成 article_field_index 上满足条件的 articleId 子查询，由 (field, 值) 索引完成过滤；没有条件时返回 nil
+func (m *extFieldMapper) extFieldSubquery(targetDB *gorm.DB, field string, cond extFieldCond) (*gorm.DB, error) {
+	t := m.types[field]
+	col := extIndexColumn(t.Type)
+	sub := targetDB.Table(models.TableNameArticleFieldIndex).Select("articleId").Where("field = ?", field)
+	filtered := false
+
+	if cond.eq.value != "" {
+		v, dateOnly, err := extFilterValue(cond.eq.name, t, cond.eq.value)
+		if err != nil {
+			return nil, err
+		}
+		if dateOnly {
+			day := v.(time.Time)
+			sub = sub.Where(col+" >= ? AND "+col+" < ?", day, day.AddDate(0, 0, 1))
+		} else {
+			sub = sub.Where(col+" = ?", v)
+		}
+		filtered = true
+	}
+
+	if cond.in.value != "" {
+		var values []interface{}
+		for _, s := range strings.Split(cond.in.value, ",") {
+			if strings.TrimSpace(s) == "" {
+				continue
+			}
+			v, dateOnly, err := extFilterValue(cond.in.name, t, s)
+			if err != nil {
+				return nil, err
+			}
+			if dateOnly {
+				return nil, fmt.Errorf("%s 不支持只包含日期的值，请使用 From/To 范围过滤", cond.in.name)
+			}
+			values = append(values, v)
+		}
+		if len(values) > 0 {
+			sub = sub.Where(col+" IN ?", values)
+			filtered = true
+		}
+	}
+
+	for i, p := range []extParam{cond.from, cond.to} {
+		if p.value == "" {
+			continue
+		}
+		if t.Type != extFieldTypeInt && t.Type != extFieldTypeDate {
+			return nil, fmt.Errorf("%s: 只有 int、date 类型的字段支持范围过滤", p.name)
+		}
+		v, dateOnly, err := extFilterValue(p.name, t, p.value)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case i == 0:
+			sub = sub.Where(col+" >= ?", v)
+		case dateOnly:
+			sub = sub.Where(col+" < ?", v.(time.Time).AddDate(0, 0, 1))
+		default:
+			sub = sub.Where(col+" <= ?", v)
+		}
+		filtered = true
+	}
+
+	if !filtered {
+		return nil, nil
+	}
+	return sub, nil
+}
+
+// joinExtSort 按扩展字段排序时关联 article_field_index 取排序值，没有该字段的文章排序值为 NULL
+func (s articleSort) joinExtSort(query *gorm.DB) *gorm.DB {
+	if s.extField == "" {
+		return query
+	}
+	return query.Joins(fmt.Sprintf("LEFT JOIN (SELECT articleId AS extSortArticleId, %s AS %s FROM %s WHERE field = ?) ext_sort ON ext_sort.extSortArticleId = %s.articleId",
+		extIndexColumn(s.extType), s.Column, models.TableNameArticleFieldIndex, models.TableNameArticleStatic), s.extField)
+}
@@ -0,0 +1,425 @@
+package server
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// dublinCoreNamespace RSS 中 dc:creator 使用的命名空间
+const dublinCoreNamespace = "http://purl.org/dc/elements/1.1/"
+
+// rssFeed RSS 2.0 文档；RSS 的 author 要求是邮箱，作者姓名使用 Dublin Core 的 dc:creator
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	XmlnsDC string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+// rssItem 附件大小未知，而 RSS 的 enclosure 必须带 length，因此 RSS 不输出附件，Atom 以 rel=enclosure 链接输出
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link,omitempty"`
+	Description string   `xml:"description"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Categories  []string `xml:"category"`
+	Guid        rssGuid  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+}
+
+type rssGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// atomFeed Atom 1.0 文档
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Links      []atomLink     `xml:"link"`
+	Summary    string         `xml:"summary,omitempty"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Title  string `xml:"title,attr,omitempty"`
+	Length string `xml:"length,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// feedSource 订阅源信息
+type feedSource struct {
+	id          string // 用于生成 Atom id，如 column-123
+	title       string
+	link        string
+	description string
+	domain      string // 站点域名，用于补全附件等相对地址
+	columnId    string // 栏目订阅时优先使用该栏目的文章地址
+	siteId      string // 站点订阅时优先使用该站点的文章地址
+}
+
+// GetColumnFeed 栏目订阅源
+// @Summary      栏目 RSS/Atom 订阅源
+// @Description  输出栏目最新文章的 RSS 2.0 或 Atom 订阅源，columnId 可带 .xml/.rss/.atom 后缀
+// @Tags         feeds
+// @Produce      xml
+// @Param        columnId  path   string  true   "栏目ID，如 123.xml"
+// @Param        format    query  string  false  "rss(默认) 或 atom，后缀为 .atom 时默认 atom"
+// @Param        limit     query  int     false  "文章条数，默认 20，最大 100"
+// @Param        includeChildren query bool false "为 true 时包含全部子孙栏目的文章"
+// @Success      200  {string}  string
+// @Failure      404  {object}  util.Response
+// @Router       /api/v1/webplus/feeds/column/{columnId} [get]
+func (h *Handler) GetColumnFeed(c *gin.Context) {
+	idStr, format := parseFeedParam(c, "columnId")
+	columnId, err := strconv.Atoi(idStr)
+	if err != nil {
+		util.Err(c, gin.H{"error": fmt.Sprintf("columnId 必须为数字: %s", idStr), "code": http.StatusBadRequest})
+		return
+	}
+
+	targetDB := db.GetTargetDB()
+	if targetDB == nil {
+		util.Err(c, fmt.Errorf("targetDB 未初始化"))
+		return
+	}
+
+	var column models.TColumn
+	if err := targetDB.Table(models.TableNameTColumn).Where("id = ?", columnId).Take(&column).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			util.Err(c, gin.H{"error": fmt.Sprintf("栏目不存在: %s", idStr), "code": http.StatusNotFound})
+			return
+		}
+		util.Err(c, fmt.Errorf("查询栏目失败: %v", err))
+		return
+	}
+
+	if !currentClient(c).allowsColumn(int64(columnId), strconv.Itoa(column.SiteId)) {
+		util.Err(c, gin.H{"error": fmt.Sprintf("栏目不存在: %s", idStr), "code": http.StatusNotFound})
+		return
+	}
+
+	columnIds := []int64{int64(columnId)}
+	if includeChildren, _ := strconv.ParseBool(util.GetParam(c, "includeChildren")); includeChildren {
+		if columnIds, err = expandColumnIds(targetDB, columnIds); err != nil {
+			util.Err(c, err)
+			return
+		}
+	}
+
+	domain := getSiteDomainName(column.SiteId)
+	link := column.Link
+	if link == "" {
+		link = buildColumnUrl(domain, column.UrlName)
+	}
+	src := feedSource{
+		id:          "column-" + idStr,
+		title:       column.Name,
+		link:        link,
+		description: column.Name,
+		domain:      domain,
+		columnId:    idStr,
+	}
+	scope := targetDB.Table(models.TableNameArticleDynamic).Select("articleId").Where("columnId IN ?", columnIds)
+	h.renderFeed(c, targetDB, scope, src, format)
+}
+
+// GetSiteFeed 站点订阅源
+// @Summary      站点 RSS/Atom 订阅源
+// @Description  输出站点最新文章的 RSS 2.0 或 Atom 订阅源，siteId 可带 .xml/.rss/.atom 后缀
+// @Tags         feeds
+// @Produce      xml
+// @Param        siteId    path   string  true   "站点ID，如 12.xml"
+// @Param        format    query  string  false  "rss(默认) 或 atom，后缀为 .atom 时默认 atom"
+// @Param        limit     query  int     false  "文章条数，默认 20，最大 100"
+// @Success      200  {string}  string
+// @Failure      404  {object}  util.Response
+// @Router       /api/v1/webplus/feeds/site/{siteId} [get]
+func (h *Handler) GetSiteFeed(c *gin.Context) {
+	idStr, format := parseFeedParam(c, "siteId")
+	siteId, err := strconv.Atoi(idStr)
+	if err != nil {
+		util.Err(c, gin.H{"error": fmt.Sprintf("siteId 必须为数字: %s", idStr), "code": http.StatusBadRequest})
+		return
+	}
+
+	targetDB := db.GetTargetDB()
+	if targetDB == nil {
+		util.Err(c, fmt.Errorf("targetDB 未初始化"))
+		return
+	}
+
+	// 调用方无权访问的站点同样按不存在处理
+	var site models.TSite
+	siteQuery := scopeSiteQuery(c, targetDB, targetDB.Table(models.TableNameTSite).Where("ID = ?", siteId))
+	if err := siteQuery.Take(&site).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			util.Err(c, gin.H{"error": fmt.Sprintf("站点不存在: %s", idStr), "code": http.StatusNotFound})
+			return
+		}
+		util.Err(c, fmt.Errorf("查询站点失败: %v", err))
+		return
+	}
+
+	domain := getSiteDomainName(siteId)
+	src := feedSource{
+		id:          "site-" + idStr,
+		title:       site.Name,
+		link:        absoluteUrl(domain, "/"),
+		description: site.Name,
+		domain:      domain,
+		siteId:      idStr,
+	}
+	scope := targetDB.Table(models.TableNameArticleDynamic).Select("articleId").Where("siteId = ?", idStr)
+	h.renderFeed(c, targetDB, scope, src, format)
+}
+
+// parseFeedParam 去掉路径参数的 .xml/.rss/.atom 后缀，并确定输出格式（format 参数优先）
+func parseFeedParam(c *gin.Context, key string) (string, string) {
+	id := strings.TrimSpace(c.Param(key))
+	format := "rss"
+	switch ext := path.Ext(id); ext {
+	case ".atom":
+		format = "atom"
+		id = strings.TrimSuffix(id, ext)
+	case ".xml", ".rss":
+		id = strings.TrimSuffix(id, ext)
+	}
+	if f := strings.ToLower(util.GetParam(c, "format")); f == "rss" || f == "atom" {
+		format = f
+	}
+	return id, format
+}
+
+// renderFeed 查询 scope 范围内最新发布的文章并输出订阅源
+func (h *Handler) renderFeed(c *gin.Context, targetDB *gorm.DB, scope *gorm.DB, src feedSource, format string) {
+	limit, _ := strconv.Atoi(util.GetParam(c, "limit"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	var rows []models.ArticleStatic
+	if err := targetDB.Table(models.TableNameArticleStatic).
+		Select("articleId, title, summary, creatorName, publisherName, publishTime, lastModifyTime, visitUrl").
+		Where("articleId IN (?)", scopeDynamicQuery(c, scope, "")).
+		Order("publishTime DESC, articleId DESC").
+		Limit(limit).
+		Find(&rows).Error; err != nil {
+		util.Err(c, fmt.Errorf("查询订阅文章失败: %v", err))
+		return
+	}
+
+	articleIDs := make([]int64, 0, len(rows))
+	for _, r := range rows {
+		if id, err := strconv.ParseInt(r.ArticleId, 10, 64); err == nil {
+			articleIDs = append(articleIDs, id)
+		}
+	}
+	columnMap, attachMap, err := loadArticleRelations(targetDB, articleIDs)
+	if err != nil {
+		util.Err(c, err)
+		return
+	}
+	scopeColumnMap(c, columnMap)
+
+	var body interface{}
+	contentType := "application/rss+xml; charset=utf-8"
+	if format == "atom" {
+		body = buildAtomFeed(rows, columnMap, attachMap, src)
+		contentType = "application/atom+xml; charset=utf-8"
+	} else {
+		body = buildRSSFeed(rows, columnMap, src)
+	}
+
+	out, err := xml.MarshalIndent(body, "", "  ")
+	if err != nil {
+		util.Err(c, fmt.Errorf("生成订阅源失败: %v", err))
+		return
+	}
+	c.Data(http.StatusOK, contentType, append([]byte(xml.Header), out...))
+}
+
+// feedItemLink 文章在订阅源中的链接：优先使用订阅栏目/站点对应的 article_dynamic.url
+func feedItemLink(r models.ArticleStatic, cols []models.Column, src feedSource) string {
+	for _, col := range cols {
+		if col.Url == "" {
+			continue
+		}
+		if (src.columnId != "" && strconv.Itoa(col.ColumnId) == src.columnId) ||
+			(src.siteId != "" && col.SiteId == src.siteId) {
+			return col.Url
+		}
+	}
+	if r.VisitUrl != "" {
+		return r.VisitUrl
+	}
+	for _, col := range cols {
+		if col.Url != "" {
+			return col.Url
+		}
+	}
+	return ""
+}
+
+// feedAuthor 文章作者，依次取作者、发布人
+func feedAuthor(r models.ArticleStatic) string {
+	if r.CreatorName != "" {
+		return r.CreatorName
+	}
+	return r.PublisherName
+}
+
+// attachmentType 根据附件扩展名推断 MIME 类型
+func attachmentType(p string) string {
+	if t := mime.TypeByExtension(strings.ToLower(path.Ext(p))); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+func buildRSSFeed(rows []models.ArticleStatic, columnMap map[int64][]models.Column, src feedSource) rssFeed {
+	channel := rssChannel{
+		Title:       src.title,
+		Link:        src.link,
+		Description: src.description,
+		Language:    "zh-cn",
+		Items:       make([]rssItem, 0, len(rows)),
+	}
+	for i, r := range rows {
+		id, _ := strconv.ParseInt(r.ArticleId, 10, 64)
+		cols := columnMap[id]
+		item := rssItem{
+			Title:       r.Title,
+			Link:        feedItemLink(r, cols, src),
+			Description: r.Summary,
+			Creator:     feedAuthor(r),
+			Guid:        rssGuid{Value: "webplus-article-" + r.ArticleId},
+		}
+		if r.PublishTime != nil {
+			item.PubDate = r.PublishTime.Format(time.RFC1123Z)
+			if i == 0 {
+				channel.LastBuildDate = item.PubDate
+			}
+		}
+		for _, col := range cols {
+			if col.ColumnName != "" {
+				item.Categories = append(item.Categories, col.ColumnName)
+			}
+		}
+		channel.Items = append(channel.Items, item)
+	}
+	return rssFeed{Version: "2.0", XmlnsDC: dublinCoreNamespace, Channel: channel}
+}
+
+func buildAtomFeed(rows []models.ArticleStatic, columnMap map[int64][]models.Column, attachMap map[int64][]models.Attachment, src feedSource) atomFeed {
+	feed := atomFeed{
+		Title:   src.title,
+		ID:      "urn:webplus:" + src.id,
+		Updated: time.Now().Format(time.RFC3339),
+		Entries: make([]atomEntry, 0, len(rows)),
+	}
+	if src.link != "" {
+		feed.Links = append(feed.Links, atomLink{Href: src.link, Rel: "alternate"})
+	}
+	for i, r := range rows {
+		id, _ := strconv.ParseInt(r.ArticleId, 10, 64)
+		cols := columnMap[id]
+		entry := atomEntry{
+			Title:   r.Title,
+			ID:      "urn:webplus:article:" + r.ArticleId,
+			Summary: r.Summary,
+		}
+		updated := r.LastModifyTime
+		if updated == nil {
+			updated = r.PublishTime
+		}
+		if updated != nil {
+			entry.Updated = updated.Format(time.RFC3339)
+		} else {
+			entry.Updated = feed.Updated
+		}
+		if r.PublishTime != nil {
+			entry.Published = r.PublishTime.Format(time.RFC3339)
+		}
+		if i == 0 && updated != nil {
+			feed.Updated = entry.Updated
+		}
+		if author := feedAuthor(r); author != "" {
+			entry.Author = &atomPerson{Name: author}
+		}
+		if link := feedItemLink(r, cols, src); link != "" {
+			entry.Links = append(entry.Links, atomLink{Href: link, Rel: "alternate"})
+		}
+		for _, col := range cols {
+			if col.ColumnName != "" {
+				entry.Categories = append(entry.Categories, atomCategory{Term: col.ColumnName})
+			}
+		}
+		for _, att := range attachMap[id] {
+			entry.Links = append(entry.Links, atomLink{
+				Href:  absoluteUrl(src.domain, att.Path),
+				Rel:   "enclosure",
+				Type:  attachmentType(att.Path),
+				Title: att.Name,
+			})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
+
+// absoluteUrl 将以 / 开头的站内路径补全为 http://域名/路径，已是完整地址或域名为空时原样返回
+func absoluteUrl(domain, p string) string {
+	if p == "" || domain == "" || strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://") || strings.HasPrefix(p, "//") {
+		return p
+	}
+	base := domain
+	if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		base = "http://" + base
+	}
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(p, "/")
+}
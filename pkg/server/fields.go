@@ -0,0 +1,168 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// articleFieldColumns 文章响应中可选择的字段（小写） -> article_static 列名，空字符串表示数据不在 article_static 中
+var articleFieldColumns = map[string]string{
+	"articleid":      "articleId",
+	"title":          "title",
+	"creatorname":    "creatorName",
+	"firstimgpath":   "firstImgPath",
+	"summary":        "summary",
+	"publishtime":    "publishTime",
+	"lastmodifytime": "lastModifyTime",
+	"visiturl":       "visitUrl",
+	"content":        "content",
+	"visitcount":     "visitCount",
+	"keywords":       "keywords",
+	"attachment":     "",
+	"columninfo":     "",
+}
+
+func init() {
+	for i := 1; i <= 50; i++ {
+		name := fmt.Sprintf("field%d", i)
+		articleFieldColumns[name] = name
+	}
+}
+
+// fieldProjection 单次请求返回的文章字段：配置的 EnabledFields 与请求的 fields、excludeFields 取交集
+// 扩展字段既可以用 fieldN，也可以用配置的语义名称指定
+type fieldProjection struct {
+	enabled map[string]bool // 配置允许的字段，nil 表示不限制
+	include map[string]bool // 请求 fields 参数，nil 表示不限制
+	exclude map[string]bool // 请求 excludeFields 参数
+	ext     *extFieldMapper
+	content *contentOptions // 正文输出方式
+}
+
+// enabledFieldProjection 只按配置的 EnabledFields 限制返回字段
+func (h *Handler) enabledFieldProjection() *fieldProjection {
+	p := &fieldProjection{ext: h.extFields}
+	if h.cfg.ResponseFields != nil && len(h.cfg.ResponseFields.EnabledFields) > 0 {
+		p.enabled = make(map[string]bool, len(h.cfg.ResponseFields.EnabledFields))
+		for _, f := range h.cfg.ResponseFields.EnabledFields {
+			p.enabled[strings.ToLower(strings.TrimSpace(f))] = true
+		}
+	}
+	return p
+}
+
+// parseFieldProjection 解析 fields、excludeFields 参数（逗号分隔、不区分大小写）及正文输出参数，包含未知字段时返回错误
+func (h *Handler) parseFieldProjection(c *gin.Context) (*fieldProjection, error) {
+	p := h.enabledFieldProjection()
+	var err error
+	if p.content, err = parseContentOptions(c); err != nil {
+		return nil, err
+	}
+	if s := util.GetParam(c, "fields"); s != "" {
+		if p.include, err = p.parseFieldNames("fields", s); err != nil {
+			return nil, err
+		}
+	}
+	if s := util.GetParam(c, "excludeFields"); s != "" {
+		if p.exclude, err = p.parseFieldNames("excludeFields", s); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *fieldProjection) parseFieldNames(param, s string) (map[string]bool, error) {
+	names := make(map[string]bool)
+	var unknown []string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" {
+			continue
+		}
+		if _, ok := articleFieldColumns[f]; !ok && !p.ext.isName(f) {
+			unknown = append(unknown, f)
+			continue
+		}
+		names[f] = true
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%s 包含不支持的字段: %s", param, strings.Join(unknown, ","))
+	}
+	return names, nil
+}
+
+// allows 判断字段（小写）是否返回，articleId 始终返回；columnInfo 不受 EnabledFields 配置限制
+func (p *fieldProjection) allows(name string) bool {
+	return p.allowsAny(name)
+}
+
+// allowsAny 同一字段有多个名称（fieldN 及其语义名称）时，任一名称满足条件即可；names 为小写，第一个为原字段名
+func (p *fieldProjection) allowsAny(names ...string) bool {
+	if names[0] == "articleid" {
+		return true
+	}
+	if p.enabled != nil && names[0] != "columninfo" && !containsAny(p.enabled, names) {
+		return false
+	}
+	if p.include != nil && !containsAny(p.include, names) {
+		return false
+	}
+	return !containsAny(p.exclude, names)
+}
+
+func containsAny(set map[string]bool, names []string) bool {
+	for _, n := range names {
+		if set[n] {
+			return true
+		}
+	}
+	return false
+}
+
+// filter 去掉文章响应中不需要返回的字段，siteId 用于确定扩展字段的语义名称；
+// 不在可选择字段中的键（如详情的 shortTitle、publisherName）始终返回
+func (p *fieldProjection) filter(item gin.H, siteId string) gin.H {
+	for key := range item {
+		names := []string{strings.ToLower(key)}
+		if _, ok := articleFieldColumns[names[0]]; !ok {
+			continue
+		}
+		if def, ok := p.ext.lookup(siteId, names[0]); ok {
+			names = append(names, strings.ToLower(def.Name))
+		}
+		if !p.allowsAny(names...) {
+			delete(item, key)
+		}
+	}
+	return item
+}
+
+// selectColumns 查询 article_static 时需要的列：返回的字段，以及排序、游标、Last-Modified、扩展字段映射、生成摘要依赖的列
+func (p *fieldProjection) selectColumns(s articleSort) []string {
+	seen := map[string]bool{"articleId": true, "createSiteId": true, "publishTime": true, "lastModifyTime": true}
+	columns := []string{"articleId", "createSiteId", "publishTime", "lastModifyTime"}
+	if s.Column != "" && !seen[s.Column] {
+		seen[s.Column] = true
+		columns = append(columns, s.Column)
+	}
+	var extra []string
+	for name, column := range articleFieldColumns {
+		names := []string{name}
+		for _, n := range p.ext.namesOf(name) {
+			names = append(names, strings.ToLower(n))
+		}
+		// 从正文生成摘要时即使不返回 content 也需要查询
+		needed := name == "content" && p.content != nil && p.content.excerpt > 0 && p.allows("summary")
+		if column != "" && !seen[column] && (p.allowsAny(names...) || needed) {
+			seen[column] = true
+			extra = append(extra, column)
+		}
+	}
+	// 列顺序固定，便于数据库复用执行计划
+	sort.Strings(extra)
+	return append(columns, extra...)
+}
@@ -0,0 +1,449 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+// GraphQLConfig /graphql 查询复杂度限制
+type GraphQLConfig struct {
+	// MaxDepth 查询最大嵌套层数，默认 8
+	MaxDepth int `json:"max_depth,omitempty" yaml:"maxDepth,omitempty" mapstructure:"maxDepth"`
+	// MaxQueryLength 查询语句最大长度（字节），默认 8192
+	MaxQueryLength int `json:"max_query_length,omitempty" yaml:"maxQueryLength,omitempty" mapstructure:"maxQueryLength"`
+	// MaxNodes 单次查询最多返回的站点、栏目、文章数，超出时返回错误，默认 2000
+	MaxNodes int `json:"max_nodes,omitempty" yaml:"maxNodes,omitempty" mapstructure:"maxNodes"`
+}
+
+// maxGraphQLFirst first 参数的上限，与列表接口 pageSize 的上限一致
+const maxGraphQLFirst = 100
+
+// newGraphQLSchema 解析 Schema 并绑定 resolver，按配置设置复杂度限制
+func (h *Handler) newGraphQLSchema(cfg *GraphQLConfig) *graphql.Schema {
+	maxDepth, maxQueryLength := 8, 8192
+	if cfg != nil && cfg.MaxDepth > 0 {
+		maxDepth = cfg.MaxDepth
+	}
+	if cfg != nil && cfg.MaxQueryLength > 0 {
+		maxQueryLength = cfg.MaxQueryLength
+	}
+	return graphql.MustParseSchema(graphqlSchema, &gqlResolver{h: h},
+		graphql.MaxDepth(maxDepth),
+		graphql.MaxQueryLength(maxQueryLength),
+	)
+}
+
+// gqlRequestKey context 中保存 gqlRequest 的键
+type gqlRequestKey struct{}
+
+// gqlRequest 单次 GraphQL 请求的状态：调用方、节点计数以及批量加载器
+// 返回给调用方的站点、栏目记录在 sites/columns 中，它们引用的站点、栏目记录在 siteRefs/columnRefs 中；
+// 查询了 articles 的栏目记录在 articleColumns 中，查询了关联、正文的文章记录在 relationArticles/contentArticles 中。
+// 加载器以这些ID作为批量查询的候选，同一层级的关联只查询一次，没有查询该字段的节点不会被顺带加载
+type gqlRequest struct {
+	c        *gin.Context
+	h        *Handler
+	db       *gorm.DB
+	proj     *fieldProjection
+	maxNodes int64
+	nodes    atomic.Int64
+
+	sites            idSet[int]
+	columns          idSet[int]
+	siteRefs         idSet[int]
+	columnRefs       idSet[int]
+	articleColumns   idSet[int]
+	relationArticles idSet[int64]
+	contentArticles  idSet[int64]
+
+	siteById       *batchLoader[int, *gqlSite]
+	columnById     *batchLoader[int, *gqlColumn]
+	columnList     *batchLoader[gqlColumnListKey, []*gqlColumn]
+	columnArticles *batchLoader[gqlColumnArticlesKey, []*gqlArticle]
+	relations      *batchLoader[int64, *gqlArticleRelations]
+	contents       *batchLoader[int64, string]
+	domains        *batchLoader[int, string]
+}
+
+// gqlColumnListKey 站点下或父栏目下的栏目列表，siteId、parentId 为 -1 表示不限制
+type gqlColumnListKey struct {
+	siteId   int
+	parentId int
+	first    int
+	sel      gqlSelection
+}
+
+// gqlColumnArticlesKey 栏目下的文章列表
+type gqlColumnArticlesKey struct {
+	columnId int
+	first    int
+	sortBy   string
+	order    string
+	sel      gqlSelection
+}
+
+// gqlSelection 返回的栏目、文章查询了哪些需要批量加载的字段，同一层级的兄弟节点查询的字段相同
+type gqlSelection struct {
+	articles  bool // 栏目的 articles
+	relations bool // 文章的 columns、attachments、visitUrl
+	contents  bool // 文章的 content、summary
+}
+
+// selectionOf 当前字段下 prefix 路径上的栏目、文章查询了哪些字段，prefix 为空表示当前字段本身返回栏目或文章
+func selectionOf(ctx context.Context, prefix string) gqlSelection {
+	has := func(names ...string) bool {
+		return lo.SomeBy(names, func(name string) bool { return graphql.HasSelectedField(ctx, prefix+name) })
+	}
+	return gqlSelection{
+		articles:  has("articles"),
+		relations: has("columns", "attachments", "visitUrl"),
+		contents:  has("content", "summary"),
+	}
+}
+
+// gqlArticleRelations 文章所属栏目和附件
+type gqlArticleRelations struct {
+	columns     []models.Column
+	attachments []models.Attachment
+}
+
+// gqlArticleColumns GraphQL 文章查询的列，正文按需单独加载
+var gqlArticleColumns = func() []string {
+	columns := []string{"articleId", "createSiteId", "title", "summary", "creatorName", "publishTime", "lastModifyTime",
+		"firstImgPath", "visitUrl", "visitCount", "keywords"}
+	for i := 1; i <= 50; i++ {
+		columns = append(columns, fmt.Sprintf("field%d", i))
+	}
+	return columns
+}()
+
+// newGQLRequest 创建请求状态和加载器
+func (h *Handler) newGQLRequest(c *gin.Context, targetDB *gorm.DB) *gqlRequest {
+	r := &gqlRequest{c: c, h: h, db: targetDB, proj: h.enabledFieldProjection(), maxNodes: 2000}
+	if h.cfg.GraphQL != nil && h.cfg.GraphQL.MaxNodes > 0 {
+		r.maxNodes = int64(h.cfg.GraphQL.MaxNodes)
+	}
+
+	r.siteById = newBatchLoader(func(int) []int { return r.siteRefs.list() }, r.fetchSites)
+	r.columnById = newBatchLoader(func(int) []int { return r.columnRefs.list() }, r.fetchColumns)
+	r.columnList = newBatchLoader(func(key gqlColumnListKey) []gqlColumnListKey {
+		// 站点下的栏目以已返回的站点为候选，子栏目以已返回的栏目为候选，其余参数相同
+		var keys []gqlColumnListKey
+		if key.siteId >= 0 {
+			for _, id := range r.sites.list() {
+				keys = append(keys, gqlColumnListKey{siteId: id, parentId: key.parentId, first: key.first, sel: key.sel})
+			}
+		} else {
+			for _, id := range r.columns.list() {
+				keys = append(keys, gqlColumnListKey{siteId: -1, parentId: id, first: key.first, sel: key.sel})
+			}
+		}
+		return keys
+	}, r.fetchColumnList)
+	r.columnArticles = newBatchLoader(func(key gqlColumnArticlesKey) []gqlColumnArticlesKey {
+		return lo.Map(r.articleColumns.list(), func(id int, _ int) gqlColumnArticlesKey {
+			k := key
+			k.columnId = id
+			return k
+		})
+	}, r.fetchColumnArticles)
+	r.relations = newBatchLoader(func(int64) []int64 { return r.relationArticles.list() }, r.fetchRelations)
+	r.contents = newBatchLoader(func(int64) []int64 { return r.contentArticles.list() }, r.fetchContents)
+	r.domains = newBatchLoader(func(int) []int { return r.siteRefs.list() }, func(siteIds []int) (map[int]string, error) {
+		return loadSiteDomainsById(r.db, siteIds), nil
+	})
+	return r
+}
+
+// gqlRequestFrom 取出 context 中的请求状态
+func gqlRequestFrom(ctx context.Context) *gqlRequest {
+	return ctx.Value(gqlRequestKey{}).(*gqlRequest)
+}
+
+// spend 记录返回的节点数，超过 MaxNodes 时返回错误
+func (r *gqlRequest) spend(n int) error {
+	if total := r.nodes.Add(int64(n)); total > r.maxNodes {
+		return fmt.Errorf("查询返回的站点、栏目、文章总数超过上限 %d，请减少嵌套或 first、pageSize", r.maxNodes)
+	}
+	return nil
+}
+
+// newSites 包装站点并记录ID
+func (r *gqlRequest) newSites(infos []SiteInfo) []*gqlSite {
+	list := make([]*gqlSite, len(infos))
+	for i := range infos {
+		list[i] = &gqlSite{req: r, info: infos[i]}
+		r.sites.add(infos[i].SiteId)
+	}
+	return list
+}
+
+// newColumns 批量生成栏目地址、路径，包装栏目并记录ID及其引用的站点、父栏目
+func (r *gqlRequest) newColumns(columns []models.TColumn) []*gqlColumn {
+	infos := r.h.buildColumnInfos(r.db, columns)
+	list := make([]*gqlColumn, len(columns))
+	for i := range columns {
+		list[i] = &gqlColumn{req: r, col: columns[i], info: infos[i]}
+		r.columns.add(columns[i].Id)
+		r.siteRefs.add(columns[i].SiteId)
+		if columns[i].ParentId > 0 {
+			r.columnRefs.add(columns[i].ParentId)
+		}
+	}
+	return list
+}
+
+// newArticles 包装文章并记录创建站点，columnId 不为 0 时 visitUrl 使用该栏目下的地址
+func (r *gqlRequest) newArticles(rows []articleRow, columnId int) []*gqlArticle {
+	list := make([]*gqlArticle, len(rows))
+	for i := range rows {
+		list[i] = &gqlArticle{req: r, row: rows[i], columnId: columnId}
+		if siteId, err := strconv.Atoi(rows[i].CreateSiteId); err == nil {
+			r.siteRefs.add(siteId)
+		}
+	}
+	return list
+}
+
+// selectColumns 查询了 articles 的栏目作为栏目文章加载器的候选
+func (r *gqlRequest) selectColumns(sel gqlSelection, columns ...*gqlColumn) {
+	if !sel.articles {
+		return
+	}
+	for _, col := range columns {
+		if col != nil {
+			r.articleColumns.add(col.col.Id)
+		}
+	}
+}
+
+// selectArticles 查询了关联、正文的文章作为对应加载器的候选
+func (r *gqlRequest) selectArticles(sel gqlSelection, articles ...*gqlArticle) {
+	for _, a := range articles {
+		if a == nil {
+			continue
+		}
+		if sel.relations {
+			r.relationArticles.add(a.row.ArticleId)
+		}
+		if sel.contents {
+			r.contentArticles.add(a.row.ArticleId)
+		}
+	}
+}
+
+// fetchSites 按ID批量查询调用方可访问的站点
+func (r *gqlRequest) fetchSites(ids []int) (map[int]*gqlSite, error) {
+	var sites []models.TSite
+	if err := scopeSiteQuery(r.c, r.db, r.db.Table(models.TableNameTSite)).
+		Where("ID IN ?", ids).
+		Find(&sites).Error; err != nil {
+		return nil, fmt.Errorf("查询站点失败: %v", err)
+	}
+	return lo.KeyBy(r.newSites(buildSiteInfos(r.db, sites)), func(s *gqlSite) int { return s.info.SiteId }), nil
+}
+
+// fetchColumns 按ID批量查询调用方可访问的栏目
+func (r *gqlRequest) fetchColumns(ids []int) (map[int]*gqlColumn, error) {
+	var columns []models.TColumn
+	if err := scopeColumnQuery(r.c, r.db.Table(models.TableNameTColumn)).
+		Where("id IN ?", ids).
+		Find(&columns).Error; err != nil {
+		return nil, fmt.Errorf("查询栏目失败: %v", err)
+	}
+	return lo.KeyBy(r.newColumns(columns), func(col *gqlColumn) int { return col.col.Id }), nil
+}
+
+// fetchColumnList 批量查询多个站点或父栏目下的栏目，每个 key 最多 first 条，按 sort、id 升序；
+// 顺带加载的其他 key 也计入节点数，避免通过嵌套放大查询量
+func (r *gqlRequest) fetchColumnList(keys []gqlColumnListKey) (map[gqlColumnListKey][]*gqlColumn, error) {
+	subs := make([]interface{}, len(keys))
+	for i, k := range keys {
+		query := scopeColumnQuery(r.c, r.db.Table(models.TableNameTColumn))
+		if k.siteId >= 0 {
+			query = query.Where("siteId = ?", strconv.Itoa(k.siteId))
+		}
+		if k.parentId >= 0 {
+			query = query.Where("parentId = ?", k.parentId)
+		}
+		subs[i] = query.Order("sort ASC, id ASC").Limit(k.first)
+	}
+	var columns []models.TColumn
+	if err := unionAll(r.db, subs).Scan(&columns).Error; err != nil {
+		return nil, fmt.Errorf("查询栏目列表失败: %v", err)
+	}
+	if err := r.spend(len(columns)); err != nil {
+		return nil, err
+	}
+	// UNION ALL 不保证各部分的顺序，按 key 分组后重新排序
+	sort.SliceStable(columns, func(i, j int) bool {
+		if columns[i].Sort != columns[j].Sort {
+			return columns[i].Sort < columns[j].Sort
+		}
+		return columns[i].Id < columns[j].Id
+	})
+	wrapped := r.newColumns(columns)
+	result := make(map[gqlColumnListKey][]*gqlColumn, len(keys))
+	for _, k := range keys {
+		list := make([]*gqlColumn, 0)
+		for _, col := range wrapped {
+			if (k.siteId < 0 || col.col.SiteId == k.siteId) && (k.parentId < 0 || col.col.ParentId == k.parentId) && len(list) < k.first {
+				list = append(list, col)
+			}
+		}
+		result[k] = list
+		r.selectColumns(k.sel, list...)
+	}
+	return result, nil
+}
+
+// fetchColumnArticles 批量查询多个栏目下调用方可访问的文章，每个栏目最多 first 篇；顺带加载的其他栏目也计入节点数
+func (r *gqlRequest) fetchColumnArticles(keys []gqlColumnArticlesKey) (map[gqlColumnArticlesKey][]*gqlArticle, error) {
+	sortSpec, err := parseArticleSort(keys[0].sortBy, keys[0].order, r.h.extFields)
+	if err != nil {
+		return nil, err
+	}
+	columns := gqlArticleColumns
+	if !lo.Contains(columns, sortSpec.Column) {
+		columns = append(append([]string(nil), columns...), sortSpec.Column)
+	}
+	subs := make([]interface{}, len(keys))
+	for i, k := range keys {
+		query := scopeArticleQuery(r.c, r.db, r.db.Table(models.TableNameArticleStatic), "articleId").
+			Where("articleId IN (?)", r.db.Table(models.TableNameArticleDynamic).Select("articleId").Where("columnId = ?", k.columnId))
+		subs[i] = sortSpec.joinExtSort(query).
+			Select(append([]string{fmt.Sprintf("%d AS gqlColumnId", k.columnId)}, columns...)).
+			Order(sortSpec.orderBy()).
+			Limit(k.first)
+	}
+	var rows []struct {
+		GqlColumnId int        `gorm:"column:gqlColumnId"`
+		Row         articleRow `gorm:"embedded"`
+	}
+	if err := unionAll(r.db, subs).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询栏目文章失败: %v", err)
+	}
+	if err := r.spend(len(rows)); err != nil {
+		return nil, err
+	}
+	// UNION ALL 不保证各部分的顺序，按 key 分组后重新排序
+	sort.SliceStable(rows, func(i, j int) bool { return sortSpec.less(rows[i].Row, rows[j].Row) })
+	grouped := make(map[int][]articleRow)
+	for _, row := range rows {
+		grouped[row.GqlColumnId] = append(grouped[row.GqlColumnId], row.Row)
+	}
+	result := make(map[gqlColumnArticlesKey][]*gqlArticle, len(keys))
+	for _, k := range keys {
+		result[k] = r.newArticles(grouped[k.columnId], k.columnId)
+		r.selectArticles(k.sel, result[k]...)
+	}
+	return result, nil
+}
+
+// fetchRelations 批量查询文章所属栏目（去掉调用方无权访问的栏目）和附件
+func (r *gqlRequest) fetchRelations(ids []int64) (map[int64]*gqlArticleRelations, error) {
+	columnMap, attachMap, err := loadArticleRelations(r.db, ids)
+	if err != nil {
+		return nil, err
+	}
+	scopeColumnMap(r.c, columnMap)
+	result := make(map[int64]*gqlArticleRelations, len(ids))
+	for _, id := range ids {
+		cols := columnMap[id]
+		sort.Slice(cols, func(i, j int) bool { return cols[i].ColumnId < cols[j].ColumnId })
+		for _, col := range cols {
+			r.columnRefs.add(col.ColumnId)
+		}
+		result[id] = &gqlArticleRelations{columns: cols, attachments: attachMap[id]}
+	}
+	return result, nil
+}
+
+// fetchContents 批量查询文章正文
+func (r *gqlRequest) fetchContents(ids []int64) (map[int64]string, error) {
+	var rows []struct {
+		ArticleId int64  `gorm:"column:articleId"`
+		Content   string `gorm:"column:content"`
+	}
+	if err := r.db.Table(models.TableNameArticleStatic).
+		Select("articleId, content").
+		Where("articleId IN ?", ids).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询文章正文失败: %v", err)
+	}
+	result := make(map[int64]string, len(rows))
+	for _, row := range rows {
+		result[row.ArticleId] = row.Content
+	}
+	return result, nil
+}
+
+// unionAll 用 UNION ALL 合并多个子查询，每个子查询可以有自己的 ORDER BY 和 LIMIT
+func unionAll(targetDB *gorm.DB, subs []interface{}) *gorm.DB {
+	return targetDB.Raw(strings.TrimSuffix(strings.Repeat("(?) UNION ALL ", len(subs)), " UNION ALL "), subs...)
+}
+
+// graphqlParams GraphQL 请求参数
+type graphqlParams struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// GraphQL 站点、栏目、文章的 GraphQL 查询
+// @Summary      GraphQL 查询
+// @Description  以 GraphQL 查询站点、栏目、文章及其关联：site → columns → articles，article → columns/attachments。过滤、排序与 getSites、getColumns、getArticles 相同，关联数据按层批量查询。
+// @Description  查询嵌套层数、语句长度和返回节点数受 graphql 配置限制；Schema 可以通过内省查询获取。响应为标准 GraphQL 格式 {data, errors}
+// @Tags         graphql
+// @Accept       json
+// @Produce      json
+// @Param        query          query  string         false  "GET：查询语句"
+// @Param        operationName  query  string         false  "GET：操作名"
+// @Param        variables      query  string         false  "GET：变量，JSON 对象"
+// @Param        body           body   graphqlParams  false  "POST：{query, operationName, variables}"
+// @Success      200  {object}  object
+// @Failure      400  {object}  util.Response
+// @Router       /api/v1/webplus/graphql [get]
+// @Router       /api/v1/webplus/graphql [post]
+func (h *Handler) GraphQL(c *gin.Context) {
+	params := graphqlParams{
+		Query:         util.GetParam(c, "query"),
+		OperationName: util.GetParam(c, "operationName"),
+	}
+	if s := c.Query("variables"); s != "" {
+		if err := json.Unmarshal([]byte(s), &params.Variables); err != nil {
+			util.Err(c, gin.H{"error": fmt.Sprintf("variables 格式错误: %v", err), "code": http.StatusBadRequest})
+			return
+		}
+	} else if _, err := util.BindJSONParam(c, "variables", &params.Variables); err != nil {
+		util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+		return
+	}
+	if strings.TrimSpace(params.Query) == "" {
+		util.Err(c, gin.H{"error": "query 不能为空", "code": http.StatusBadRequest})
+		return
+	}
+
+	targetDB := db.GetTargetDB()
+	if targetDB == nil {
+		util.Err(c, fmt.Errorf("targetDB 未初始化"))
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), gqlRequestKey{}, h.newGQLRequest(c, targetDB))
+	c.JSON(http.StatusOK, h.graphql.Exec(ctx, params.Query, params.OperationName, params.Variables))
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"sync"
+)
+
+// batchLoader 单次 GraphQL 请求内的批量加载器
+// 第一次按某个 key 取值时，把同一请求中已出现、尚未加载的同类 key（candidates 返回）一起查询，
+// 同一层级的兄弟节点因此只产生一次查询；结果缓存到请求结束，不存在的 key 缓存零值
+type batchLoader[K comparable, V any] struct {
+	mu         sync.Mutex
+	cache      map[K]V
+	candidates func(key K) []K
+	fetch      func(keys []K) (map[K]V, error)
+}
+
+func newBatchLoader[K comparable, V any](candidates func(key K) []K, fetch func(keys []K) (map[K]V, error)) *batchLoader[K, V] {
+	return &batchLoader[K, V]{cache: make(map[K]V), candidates: candidates, fetch: fetch}
+}
+
+// load 取 key 对应的值，未缓存时与其他候选 key 一起批量查询
+func (l *batchLoader[K, V]) load(key K) (V, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if v, ok := l.cache[key]; ok {
+		return v, nil
+	}
+	keys := []K{key}
+	seen := map[K]bool{key: true}
+	if l.candidates != nil {
+		for _, k := range l.candidates(key) {
+			if _, cached := l.cache[k]; !cached && !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	values, err := l.fetch(keys)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	for _, k := range keys {
+		l.cache[k] = values[k]
+	}
+	return l.cache[key], nil
+}
+
+// prime 写入已查询到的值，避免再次查询
+func (l *batchLoader[K, V]) prime(key K, v V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.cache[key]; !ok {
+		l.cache[key] = v
+	}
+}
+
+// idSet 请求中已出现的ID，保持出现顺序，作为批量加载的候选 key
+type idSet[K comparable] struct {
+	mu   sync.Mutex
+	ids  []K
+	seen map[K]bool
+}
+
+func (s *idSet[K]) add(ids ...K) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen == nil {
+		s.seen = make(map[K]bool)
+	}
+	for _, id := range ids {
+		if !s.seen[id] {
+			s.seen[id] = true
+			s.ids = append(s.ids, id)
+		}
+	}
+}
+
+func (s *idSet[K]) list() []K {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]K(nil), s.ids...)
+}
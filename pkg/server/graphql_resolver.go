@@ -0,0 +1,752 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"gorm.io/gorm"
+)
+
+// gqlResolver GraphQL 查询入口
+type gqlResolver struct {
+	h *Handler
+}
+
+// gqlPagination 列表分页信息
+type gqlPagination struct {
+	page, pageSize int
+	total          int64
+}
+
+func (p gqlPagination) Page() int32     { return int32(p.page) }
+func (p gqlPagination) PageSize() int32 { return int32(p.pageSize) }
+func (p gqlPagination) HasNext() bool   { return int64(p.page*p.pageSize) < p.total }
+func (p gqlPagination) Total() int32    { return int32(p.total) }
+
+// gqlPaging 与 parsePaging 相同：pageSize 默认 20、最大 100
+func gqlPaging(page, pageSize int32) (int, int) {
+	p, size := 1, 20
+	if page > 1 {
+		p = int(page)
+	}
+	if pageSize >= 1 && pageSize <= maxGraphQLFirst {
+		size = int(pageSize)
+	}
+	return p, size
+}
+
+// gqlFirst first 参数，必须为 1-100
+func gqlFirst(first int32) (int, error) {
+	if first < 1 || first > maxGraphQLFirst {
+		return 0, fmt.Errorf("first 必须为 1-%d 的整数: %d", maxGraphQLFirst, first)
+	}
+	return int(first), nil
+}
+
+// gqlID 将 ID 参数转换为数字
+func gqlID(name string, id graphql.ID) (int, error) {
+	v, err := strconv.Atoi(strings.TrimSpace(string(id)))
+	if err != nil {
+		return 0, fmt.Errorf("%s 必须为数字: %s", name, id)
+	}
+	return v, nil
+}
+
+// gqlIDs 将 ID 列表参数转换为 jsonValues，与 REST 的 siteId、columnId 参数一致
+func gqlIDs(ids *[]graphql.ID) jsonValues {
+	if ids == nil {
+		return nil
+	}
+	values := make(jsonValues, 0, len(*ids))
+	for _, id := range *ids {
+		if s := strings.TrimSpace(string(id)); s != "" {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// gqlString 可选字符串参数，未传时为空字符串
+func gqlString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// gqlTime 转换为 GraphQL Time
+func gqlTime(t *time.Time) *graphql.Time {
+	if t == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *t}
+}
+
+type gqlSitePage struct {
+	items      []*gqlSite
+	pagination gqlPagination
+}
+
+func (p *gqlSitePage) Items() []*gqlSite         { return p.items }
+func (p *gqlSitePage) Pagination() gqlPagination { return p.pagination }
+
+type gqlColumnPage struct {
+	items      []*gqlColumn
+	pagination gqlPagination
+}
+
+func (p *gqlColumnPage) Items() []*gqlColumn       { return p.items }
+func (p *gqlColumnPage) Pagination() gqlPagination { return p.pagination }
+
+type gqlArticlePage struct {
+	items      []*gqlArticle
+	pagination gqlPagination
+}
+
+func (p *gqlArticlePage) Items() []*gqlArticle      { return p.items }
+func (p *gqlArticlePage) Pagination() gqlPagination { return p.pagination }
+
+// Sites 站点列表
+func (r *gqlResolver) Sites(ctx context.Context, args struct {
+	Ids      *[]graphql.ID
+	Name     *string
+	Page     int32
+	PageSize int32
+}) (*gqlSitePage, error) {
+	req := gqlRequestFrom(ctx)
+	page, pageSize := gqlPaging(args.Page, args.PageSize)
+	siteIds, err := filterIDs("ids", gqlIDs(args.Ids))
+	if err != nil {
+		return nil, err
+	}
+	query := siteListQuery(req.c, req.db, siteIds, gqlString(args.Name))
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("统计站点总数失败: %v", err)
+	}
+	var sites []models.TSite
+	if err := query.Order("ID ASC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&sites).Error; err != nil {
+		return nil, fmt.Errorf("查询站点列表失败: %v", err)
+	}
+	if err := req.spend(len(sites)); err != nil {
+		return nil, err
+	}
+	return &gqlSitePage{
+		items:      req.newSites(buildSiteInfos(req.db, sites)),
+		pagination: gqlPagination{page: page, pageSize: pageSize, total: total},
+	}, nil
+}
+
+// Site 按ID获取站点，不存在或无权访问时返回 null
+func (r *gqlResolver) Site(ctx context.Context, args struct{ Id graphql.ID }) (*gqlSite, error) {
+	req := gqlRequestFrom(ctx)
+	id, err := gqlID("id", args.Id)
+	if err != nil {
+		return nil, err
+	}
+	return req.siteById.load(id)
+}
+
+// Columns 栏目列表
+func (r *gqlResolver) Columns(ctx context.Context, args struct {
+	SiteIds  *[]graphql.ID
+	ParentId *graphql.ID
+	Name     *string
+	Page     int32
+	PageSize int32
+}) (*gqlColumnPage, error) {
+	req := gqlRequestFrom(ctx)
+	page, pageSize := gqlPaging(args.Page, args.PageSize)
+	siteIds, err := filterIDs("siteIds", gqlIDs(args.SiteIds))
+	if err != nil {
+		return nil, err
+	}
+	var parentId string
+	if args.ParentId != nil {
+		id, err := gqlID("parentId", *args.ParentId)
+		if err != nil {
+			return nil, err
+		}
+		parentId = strconv.Itoa(id)
+	}
+	query := columnListQuery(req.c, req.db, siteIds, parentId, gqlString(args.Name))
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("统计栏目总数失败: %v", err)
+	}
+	var columns []models.TColumn
+	if err := query.Order("id ASC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&columns).Error; err != nil {
+		return nil, fmt.Errorf("查询栏目列表失败: %v", err)
+	}
+	if err := req.spend(len(columns)); err != nil {
+		return nil, err
+	}
+	items := req.newColumns(columns)
+	req.selectColumns(selectionOf(ctx, "items."), items...)
+	return &gqlColumnPage{
+		items:      items,
+		pagination: gqlPagination{page: page, pageSize: pageSize, total: total},
+	}, nil
+}
+
+// Column 按ID获取栏目，不存在或无权访问时返回 null
+func (r *gqlResolver) Column(ctx context.Context, args struct{ Id graphql.ID }) (*gqlColumn, error) {
+	req := gqlRequestFrom(ctx)
+	id, err := gqlID("id", args.Id)
+	if err != nil {
+		return nil, err
+	}
+	col, err := req.columnById.load(id)
+	if err != nil {
+		return nil, err
+	}
+	req.selectColumns(selectionOf(ctx, ""), col)
+	return col, nil
+}
+
+// gqlArticleFilter GraphQL 的 ArticleFilter 输入
+type gqlArticleFilter struct {
+	Must            *[]gqlArticleFilter
+	Should          *[]gqlArticleFilter
+	MustNot         *[]gqlArticleFilter
+	Site            *[]graphql.ID
+	Column          *[]graphql.ID
+	IncludeChildren *bool
+	Field           *struct {
+		Name string
+		Eq   *string
+		In   *[]string
+		From *string
+		To   *string
+		Like *string
+	}
+	Time *struct {
+		Field *string
+		From  *string
+		To    *string
+	}
+}
+
+// toFilter 转换为 getArticles 使用的 ArticleFilter
+func (f *gqlArticleFilter) toFilter() ArticleFilter {
+	var filter ArticleFilter
+	if f == nil {
+		return filter
+	}
+	subs := func(list *[]gqlArticleFilter) []ArticleFilter {
+		if list == nil {
+			return nil
+		}
+		result := make([]ArticleFilter, len(*list))
+		for i := range *list {
+			result[i] = (&(*list)[i]).toFilter()
+		}
+		return result
+	}
+	filter.Must, filter.Should, filter.MustNot = subs(f.Must), subs(f.Should), subs(f.MustNot)
+	filter.Site, filter.Column = gqlIDs(f.Site), gqlIDs(f.Column)
+	filter.IncludeChildren = f.IncludeChildren != nil && *f.IncludeChildren
+	if f.Field != nil {
+		filter.Field = &ArticleFieldMatch{
+			Name: f.Field.Name,
+			Eq:   jsonValue(gqlString(f.Field.Eq)),
+			From: jsonValue(gqlString(f.Field.From)),
+			To:   jsonValue(gqlString(f.Field.To)),
+			Like: gqlString(f.Field.Like),
+		}
+		if f.Field.In != nil {
+			filter.Field.In = *f.Field.In
+		}
+	}
+	if f.Time != nil {
+		filter.Time = &ArticleTimeRange{Field: gqlString(f.Time.Field), From: gqlString(f.Time.From), To: gqlString(f.Time.To)}
+	}
+	return filter
+}
+
+// gqlArticleSort GraphQL 的 ArticleSort 输入
+type gqlArticleSort struct {
+	By    string
+	Order *string
+}
+
+// parse 解析排序方式，未传时默认 publishTime DESC
+func (s *gqlArticleSort) parse(ext *extFieldMapper) (articleSort, error) {
+	if s == nil {
+		return parseArticleSort("", "", ext)
+	}
+	return parseArticleSort(s.By, gqlString(s.Order), ext)
+}
+
+// Articles 文章列表，条件与 getArticles 相同并转换为 filter 编译
+func (r *gqlResolver) Articles(ctx context.Context, args struct {
+	SiteIds         *[]graphql.ID
+	ColumnIds       *[]graphql.ID
+	IncludeChildren bool
+	Title           *string
+	Filter          *gqlArticleFilter
+	Sort            *gqlArticleSort
+	Page            int32
+	PageSize        int32
+}) (*gqlArticlePage, error) {
+	req := gqlRequestFrom(ctx)
+	page, pageSize := gqlPaging(args.Page, args.PageSize)
+	sortSpec, err := args.Sort.parse(r.h.extFields)
+	if err != nil {
+		return nil, err
+	}
+
+	// 如果同时传 columnIds 和 siteIds，则只看 columnIds
+	filter := args.Filter.toFilter()
+	var filterColumnId int
+	if columnIds := gqlIDs(args.ColumnIds); len(columnIds) > 0 {
+		filterColumnId, _ = strconv.Atoi(columnIds[0])
+		filter.Must = append(filter.Must, ArticleFilter{Column: columnIds, IncludeChildren: args.IncludeChildren})
+	} else if siteIds := gqlIDs(args.SiteIds); len(siteIds) > 0 {
+		filter.Must = append(filter.Must, ArticleFilter{Site: siteIds})
+	}
+	if title := gqlString(args.Title); title != "" {
+		filter.Must = append(filter.Must, ArticleFilter{Field: &ArticleFieldMatch{Name: "title", Like: title}})
+	}
+	cond, condArgs, err := r.h.compileArticleFilter(req.db, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	query := req.db.Table(models.TableNameArticleStatic)
+	if cond != "" {
+		query = query.Where(cond, condArgs...)
+	}
+	query = scopeArticleQuery(req.c, req.db, query, "articleId")
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("统计文章总数失败: %v", err)
+	}
+	var rows []articleRow
+	if err := sortSpec.joinExtSort(query).
+		Order(sortSpec.orderBy()).
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Select(gqlArticleColumns).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询文章列表失败: %v", err)
+	}
+	if err := req.spend(len(rows)); err != nil {
+		return nil, err
+	}
+	items := req.newArticles(rows, filterColumnId)
+	req.selectArticles(selectionOf(ctx, "items."), items...)
+	return &gqlArticlePage{
+		items:      items,
+		pagination: gqlPagination{page: page, pageSize: pageSize, total: total},
+	}, nil
+}
+
+// Article 按ID获取文章，不存在或无权访问时返回 null
+func (r *gqlResolver) Article(ctx context.Context, args struct {
+	Id       graphql.ID
+	ColumnId *graphql.ID
+}) (*gqlArticle, error) {
+	req := gqlRequestFrom(ctx)
+	id, err := gqlID("id", args.Id)
+	if err != nil {
+		return nil, err
+	}
+	var columnId int
+	if args.ColumnId != nil {
+		if columnId, err = gqlID("columnId", *args.ColumnId); err != nil {
+			return nil, err
+		}
+	}
+	var row articleRow
+	if err := scopeArticleQuery(req.c, req.db, req.db.Table(models.TableNameArticleStatic), "articleId").
+		Where("articleId = ?", id).
+		Select(gqlArticleColumns).
+		Take(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询文章详情失败: %v", err)
+	}
+	if err := req.spend(1); err != nil {
+		return nil, err
+	}
+	article := req.newArticles([]articleRow{row}, columnId)[0]
+	req.selectArticles(selectionOf(ctx, ""), article)
+	return article, nil
+}
+
+// gqlSite 站点
+type gqlSite struct {
+	req  *gqlRequest
+	info SiteInfo
+}
+
+func (s *gqlSite) Id() graphql.ID    { return graphql.ID(strconv.Itoa(s.info.SiteId)) }
+func (s *gqlSite) Name() string      { return s.info.SiteName }
+func (s *gqlSite) ShortName() string { return s.info.ShortName }
+func (s *gqlSite) Published() bool   { return s.info.Status == 1 }
+func (s *gqlSite) Url() string       { return s.info.SiteUrl }
+func (s *gqlSite) Logo() string      { return s.info.Logo }
+
+// Columns 站点下的栏目，同一层级的所有站点一次查询
+func (s *gqlSite) Columns(ctx context.Context, args struct {
+	ParentId *graphql.ID
+	First    int32
+}) ([]*gqlColumn, error) {
+	first, err := gqlFirst(args.First)
+	if err != nil {
+		return nil, err
+	}
+	parentId := -1
+	if args.ParentId != nil {
+		if parentId, err = gqlID("parentId", *args.ParentId); err != nil {
+			return nil, err
+		}
+	}
+	return s.req.columnList.load(gqlColumnListKey{siteId: s.info.SiteId, parentId: parentId, first: first, sel: selectionOf(ctx, "")})
+}
+
+// gqlColumn 栏目
+type gqlColumn struct {
+	req  *gqlRequest
+	col  models.TColumn
+	info ColumnInfo
+}
+
+func (c *gqlColumn) Id() graphql.ID       { return graphql.ID(strconv.Itoa(c.col.Id)) }
+func (c *gqlColumn) Name() string         { return c.col.Name }
+func (c *gqlColumn) ParentId() graphql.ID { return graphql.ID(strconv.Itoa(c.col.ParentId)) }
+func (c *gqlColumn) Url() string          { return c.info.ColumnUrl }
+func (c *gqlColumn) Path() string         { return c.info.Path }
+func (c *gqlColumn) Sort() int32          { return int32(c.col.Sort) }
+func (c *gqlColumn) Navigation() int32    { return int32(c.col.Navigation) }
+
+// Site 栏目所在站点
+func (c *gqlColumn) Site() (*gqlSite, error) {
+	return c.req.siteById.load(c.col.SiteId)
+}
+
+// Parent 父栏目，顶级栏目或无权访问时返回 null
+func (c *gqlColumn) Parent(ctx context.Context) (*gqlColumn, error) {
+	if c.col.ParentId <= 0 {
+		return nil, nil
+	}
+	parent, err := c.req.columnById.load(c.col.ParentId)
+	if err != nil {
+		return nil, err
+	}
+	c.req.selectColumns(selectionOf(ctx, ""), parent)
+	return parent, nil
+}
+
+// Children 子栏目，同一层级的所有栏目一次查询
+func (c *gqlColumn) Children(ctx context.Context, args struct{ First int32 }) ([]*gqlColumn, error) {
+	first, err := gqlFirst(args.First)
+	if err != nil {
+		return nil, err
+	}
+	return c.req.columnList.load(gqlColumnListKey{siteId: -1, parentId: c.col.Id, first: first, sel: selectionOf(ctx, "")})
+}
+
+// Articles 栏目下的文章，同一层级的所有栏目一次查询
+func (c *gqlColumn) Articles(ctx context.Context, args struct {
+	First int32
+	Sort  *gqlArticleSort
+}) ([]*gqlArticle, error) {
+	first, err := gqlFirst(args.First)
+	if err != nil {
+		return nil, err
+	}
+	// 先校验排序参数，加载器中不再重复报错
+	if _, err := args.Sort.parse(c.req.h.extFields); err != nil {
+		return nil, err
+	}
+	key := gqlColumnArticlesKey{columnId: c.col.Id, first: first, sel: selectionOf(ctx, "")}
+	if args.Sort != nil {
+		key.sortBy, key.order = args.Sort.By, gqlString(args.Sort.Order)
+	}
+	return c.req.columnArticles.load(key)
+}
+
+// gqlArticle 文章
+type gqlArticle struct {
+	req      *gqlRequest
+	row      articleRow
+	columnId int // 不为 0 时 visitUrl 使用文章在该栏目下的地址
+}
+
+// text 字段允许返回时返回值，否则返回 null
+func (a *gqlArticle) text(name, value string) *string {
+	if !a.req.proj.allows(name) {
+		return nil
+	}
+	return &value
+}
+
+func (a *gqlArticle) Id() graphql.ID       { return graphql.ID(strconv.FormatInt(a.row.ArticleId, 10)) }
+func (a *gqlArticle) SiteId() graphql.ID   { return graphql.ID(a.row.CreateSiteId) }
+func (a *gqlArticle) Title() *string       { return a.text("title", a.row.Title) }
+func (a *gqlArticle) CreatorName() *string { return a.text("creatorname", a.row.CreatorName) }
+func (a *gqlArticle) Keywords() *string    { return a.text("keywords", a.row.Keywords) }
+
+func (a *gqlArticle) PublishTime() *graphql.Time {
+	if !a.req.proj.allows("publishtime") {
+		return nil
+	}
+	return gqlTime(a.row.PublishTime)
+}
+
+func (a *gqlArticle) LastModifyTime() *graphql.Time {
+	if !a.req.proj.allows("lastmodifytime") {
+		return nil
+	}
+	return gqlTime(a.row.LastModifyTime)
+}
+
+func (a *gqlArticle) VisitCount() *int32 {
+	if !a.req.proj.allows("visitcount") {
+		return nil
+	}
+	n := int32(a.row.VisitCount)
+	return &n
+}
+
+// Summary 摘要，excerpt 大于 0 且摘要为空时从正文生成
+func (a *gqlArticle) Summary(args struct{ Excerpt int32 }) (*string, error) {
+	if !a.req.proj.allows("summary") {
+		return nil, nil
+	}
+	excerpt := int(args.Excerpt)
+	if excerpt < 0 || excerpt > maxExcerptLength {
+		return nil, fmt.Errorf("excerpt 必须为 0-%d 的整数: %d", maxExcerptLength, excerpt)
+	}
+	summary := a.row.Summary
+	if excerpt > 0 && strings.TrimSpace(summary) == "" {
+		content, err := a.req.contents.load(a.row.ArticleId)
+		if err != nil {
+			return nil, err
+		}
+		summary = excerptOf(content, excerpt)
+	}
+	return &summary, nil
+}
+
+// VisitUrl 访问地址，按栏目查询时使用文章在该栏目下的地址
+func (a *gqlArticle) VisitUrl() (*string, error) {
+	if !a.req.proj.allows("visiturl") {
+		return nil, nil
+	}
+	visitUrl := a.row.VisitUrl
+	if a.columnId != 0 {
+		rel, err := a.req.relations.load(a.row.ArticleId)
+		if err != nil {
+			return nil, err
+		}
+		for _, col := range rel.columns {
+			if col.ColumnId == a.columnId && col.Url != "" {
+				visitUrl = col.Url
+				break
+			}
+		}
+	}
+	return &visitUrl, nil
+}
+
+// domain 文章创建站点的域名
+func (a *gqlArticle) domain() (string, error) {
+	siteId, err := strconv.Atoi(a.row.CreateSiteId)
+	if err != nil {
+		return "", nil
+	}
+	return a.req.domains.load(siteId)
+}
+
+// FirstImgPath 封面图，absoluteUrls 为 true 时补全为站点域名下的绝对地址
+func (a *gqlArticle) FirstImgPath(args struct{ AbsoluteUrls bool }) (*string, error) {
+	if !a.req.proj.allows("firstimgpath") {
+		return nil, nil
+	}
+	path := a.row.FirstImgPath
+	if args.AbsoluteUrls {
+		domain, err := a.domain()
+		if err != nil {
+			return nil, err
+		}
+		if domain != "" {
+			path = absoluteUrl(domain, path)
+		}
+	}
+	return &path, nil
+}
+
+// Content 正文，与 contentFormat、absoluteUrls 参数含义相同
+func (a *gqlArticle) Content(args struct {
+	Format       string
+	AbsoluteUrls bool
+}) (*string, error) {
+	if !a.req.proj.allows("content") {
+		return nil, nil
+	}
+	content, err := a.req.contents.load(a.row.ArticleId)
+	if err != nil {
+		return nil, err
+	}
+	if args.AbsoluteUrls {
+		domain, err := a.domain()
+		if err != nil {
+			return nil, err
+		}
+		if domain != "" {
+			content = absolutizeHTML(domain, content)
+		}
+	}
+	if args.Format == "TEXT" {
+		content = util.HTMLToText(content)
+	}
+	return &content, nil
+}
+
+// Field 扩展字段，name 可以是 fieldN 或文章创建站点配置的语义名称
+func (a *gqlArticle) Field(args struct{ Name string }) *string {
+	name := strings.ToLower(strings.TrimSpace(args.Name))
+	field := ""
+	if extFieldIndex(name) > 0 {
+		field = name
+	} else {
+		for _, f := range a.req.h.extFields.fieldsOf(name) {
+			if def, ok := a.req.h.extFields.lookup(a.row.CreateSiteId, f); ok && strings.EqualFold(def.Name, name) {
+				field = f
+				break
+			}
+		}
+	}
+	if field == "" || !a.allowsField(field) {
+		return nil
+	}
+	value := a.row.ArticleFields.ToMap()[field]
+	return &value
+}
+
+// allowsField 扩展字段是否允许返回，fieldN 及其语义名称任一启用即可
+func (a *gqlArticle) allowsField(field string) bool {
+	names := []string{field}
+	if def, ok := a.req.h.extFields.lookup(a.row.CreateSiteId, field); ok {
+		names = append(names, strings.ToLower(def.Name))
+	}
+	return a.req.proj.allowsAny(names...)
+}
+
+// Fields 非空且允许返回的扩展字段，按字段序号排列
+func (a *gqlArticle) Fields() []gqlArticleField {
+	values := a.row.ArticleFields.ToMap()
+	list := make([]gqlArticleField, 0)
+	for i := 1; i <= 50; i++ {
+		field := fmt.Sprintf("field%d", i)
+		if values[field] == "" || !a.allowsField(field) {
+			continue
+		}
+		name := field
+		if def, ok := a.req.h.extFields.lookup(a.row.CreateSiteId, field); ok {
+			name = def.Name
+		}
+		list = append(list, gqlArticleField{field: field, name: name, value: values[field]})
+	}
+	return list
+}
+
+// Site 文章创建站点，无权访问时返回 null
+func (a *gqlArticle) Site() (*gqlSite, error) {
+	siteId, err := strconv.Atoi(a.row.CreateSiteId)
+	if err != nil {
+		return nil, nil
+	}
+	return a.req.siteById.load(siteId)
+}
+
+// Columns 文章所属栏目，不包括调用方无权访问的栏目
+func (a *gqlArticle) Columns() ([]*gqlArticleColumn, error) {
+	rel, err := a.req.relations.load(a.row.ArticleId)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*gqlArticleColumn, len(rel.columns))
+	for i := range rel.columns {
+		list[i] = &gqlArticleColumn{req: a.req, col: rel.columns[i]}
+	}
+	return list, nil
+}
+
+// Attachments 附件，absoluteUrls 为 true 时补全为站点域名下的绝对地址
+func (a *gqlArticle) Attachments(args struct{ AbsoluteUrls bool }) ([]gqlAttachment, error) {
+	list := make([]gqlAttachment, 0)
+	if !a.req.proj.allows("attachment") {
+		return list, nil
+	}
+	rel, err := a.req.relations.load(a.row.ArticleId)
+	if err != nil {
+		return nil, err
+	}
+	domain := ""
+	if args.AbsoluteUrls {
+		if domain, err = a.domain(); err != nil {
+			return nil, err
+		}
+	}
+	for _, att := range rel.attachments {
+		if domain != "" {
+			att.Path = absoluteUrl(domain, att.Path)
+		}
+		list = append(list, gqlAttachment{att: att})
+	}
+	return list, nil
+}
+
+// gqlArticleColumn 文章所属栏目
+type gqlArticleColumn struct {
+	req *gqlRequest
+	col models.Column
+}
+
+func (c *gqlArticleColumn) Id() graphql.ID     { return graphql.ID(strconv.Itoa(c.col.ColumnId)) }
+func (c *gqlArticleColumn) Name() string       { return c.col.ColumnName }
+func (c *gqlArticleColumn) SiteId() graphql.ID { return graphql.ID(c.col.SiteId) }
+func (c *gqlArticleColumn) SiteName() string   { return c.col.SiteName }
+func (c *gqlArticleColumn) Url() string        { return c.col.Url }
+
+// Column 栏目详情，无权访问时返回 null
+func (c *gqlArticleColumn) Column(ctx context.Context) (*gqlColumn, error) {
+	col, err := c.req.columnById.load(c.col.ColumnId)
+	if err != nil {
+		return nil, err
+	}
+	c.req.selectColumns(selectionOf(ctx, ""), col)
+	return col, nil
+}
+
+// gqlAttachment 附件
+type gqlAttachment struct {
+	att models.Attachment
+}
+
+func (a gqlAttachment) Name() string { return a.att.Name }
+func (a gqlAttachment) Path() string { return a.att.Path }
+
+// gqlArticleField 扩展字段
+type gqlArticleField struct {
+	field, name, value string
+}
+
+func (f gqlArticleField) Field() string { return f.field }
+func (f gqlArticleField) Name() string  { return f.name }
+func (f gqlArticleField) Value() string { return f.value }
@@ -0,0 +1,172 @@
+package server
+
+// graphqlSchema /graphql 的 Schema，过滤、排序参数与 REST 接口含义相同
+const graphqlSchema = `
+schema {
+	query: Query
+}
+
+scalar Time
+
+type Query {
+	"站点列表，过滤条件与 getSites 相同"
+	sites(ids: [ID!], name: String, page: Int = 1, pageSize: Int = 20): SitePage!
+	site(id: ID!): Site
+	"栏目列表，过滤条件与 getColumns 相同"
+	columns(siteIds: [ID!], parentId: ID, name: String, page: Int = 1, pageSize: Int = 20): ColumnPage!
+	column(id: ID!): Column
+	"文章列表：同时传 columnIds 和 siteIds 时只看 columnIds；filter、sort 与 POST getArticles 请求体中的 filter、sort 相同"
+	articles(
+		siteIds: [ID!]
+		columnIds: [ID!]
+		includeChildren: Boolean = false
+		title: String
+		filter: ArticleFilter
+		sort: ArticleSort
+		page: Int = 1
+		pageSize: Int = 20
+	): ArticlePage!
+	"文章详情，传 columnId 时 visitUrl 使用文章在该栏目下的地址"
+	article(id: ID!, columnId: ID): Article
+}
+
+type Pagination {
+	page: Int!
+	pageSize: Int!
+	hasNext: Boolean!
+	total: Int!
+}
+
+type SitePage {
+	items: [Site!]!
+	pagination: Pagination!
+}
+
+type ColumnPage {
+	items: [Column!]!
+	pagination: Pagination!
+}
+
+type ArticlePage {
+	items: [Article!]!
+	pagination: Pagination!
+}
+
+type Site {
+	id: ID!
+	name: String!
+	shortName: String!
+	"是否已发布，未发布的站点 url、logo 为空"
+	published: Boolean!
+	url: String!
+	logo: String!
+	"站点下的栏目，按 sort、id 升序；不传 parentId 时返回全部层级"
+	columns(parentId: ID, first: Int = 100): [Column!]!
+}
+
+type Column {
+	id: ID!
+	name: String!
+	parentId: ID!
+	url: String!
+	"中文路径，如 /系统站点/新闻/通知公告"
+	path: String!
+	sort: Int!
+	"是否导航栏目，与 getColumns 的 status 相同"
+	navigation: Int!
+	site: Site
+	parent: Column
+	children(first: Int = 100): [Column!]!
+	"栏目下的文章，默认按发布时间倒序"
+	articles(first: Int = 10, sort: ArticleSort): [Article!]!
+}
+
+enum ContentFormat {
+	HTML
+	TEXT
+}
+
+"文章；未在 response_fields.enabled_fields 中启用的字段返回 null"
+type Article {
+	id: ID!
+	"创建站点ID"
+	siteId: ID!
+	title: String
+	"excerpt 大于 0 且摘要为空时，从正文生成该长度（字符）的摘要"
+	summary(excerpt: Int = 0): String
+	creatorName: String
+	publishTime: Time
+	lastModifyTime: Time
+	visitUrl: String
+	visitCount: Int
+	keywords: String
+	firstImgPath(absoluteUrls: Boolean = false): String
+	content(format: ContentFormat = HTML, absoluteUrls: Boolean = false): String
+	"扩展字段，name 可以是 fieldN 或配置的语义名称"
+	field(name: String!): String
+	"非空的扩展字段"
+	fields: [ArticleField!]!
+	site: Site
+	columns: [ArticleColumn!]!
+	attachments(absoluteUrls: Boolean = false): [Attachment!]!
+}
+
+"文章所属栏目"
+type ArticleColumn {
+	id: ID!
+	name: String!
+	siteId: ID!
+	siteName: String!
+	"文章在该栏目下的访问地址"
+	url: String!
+	column: Column
+}
+
+type Attachment {
+	name: String!
+	path: String!
+}
+
+type ArticleField {
+	"原字段名，如 field3"
+	field: String!
+	"语义名称，未配置时为 fieldN"
+	name: String!
+	value: String!
+}
+
+"过滤条件，含义与 POST getArticles 请求体中的 filter 相同"
+input ArticleFilter {
+	must: [ArticleFilter!]
+	should: [ArticleFilter!]
+	mustNot: [ArticleFilter!]
+	site: [ID!]
+	column: [ID!]
+	includeChildren: Boolean
+	field: ArticleFieldMatch
+	time: ArticleTimeRange
+}
+
+input ArticleFieldMatch {
+	name: String!
+	eq: String
+	in: [String!]
+	from: String
+	to: String
+	like: String
+}
+
+input ArticleTimeRange {
+	"publishTime（默认）、lastModifyTime"
+	field: String
+	from: String
+	to: String
+}
+
+input ArticleSort {
+	"与 sortBy 参数相同"
+	by: String!
+	"asc、desc（默认）"
+	order: String
+}
+`
@@ -1,9 +1,10 @@
 package server
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"path"
-	"regexp"
+	"net/http"
 	"sort"
 	"strconv"
 	"strings"
@@ -18,10 +19,11 @@ import (
 
 // GetArticles 获取文章列表
 // @Summary      获取文章列表
-// @Description  按栏目、站点、时间分页获取文章
+// @Description  按栏目、站点、时间分页获取文章。声明了类型的扩展字段（extFields.types）支持 fieldN=值 精确匹配、fieldNIn=值1,值2、fieldNFrom/fieldNTo 范围过滤（int、date），参数名也可以使用语义名称
 // @Tags         articles
 // @Produce      json
 // @Param        columnId  query  string  false  "栏目ID，逗号分隔"
+// @Param        includeChildren query bool false "为 true 时包含 columnId 的全部子孙栏目中的文章"
 // @Param        siteId    query  string  false  "站点ID，逗号分隔"
 // @Param        page      query  int     false  "页码，从1开始"
 // @Param        pageSize  query  int     false  "每页大小"
@@ -30,85 +32,76 @@ import (
 // @Param        endTime   query  string  false  "结束时间，格式: 2025-01-01"
 // @Param        articleId query  string  false  "文章ID"
 // @Param        fuzzyField query  string  false  "模糊搜索字段，逗号分隔"
+// @Param        cursor    query  string  false  "游标分页，首页传空值，后续传上一页返回的 nextCursor"
+// @Param        withTotal query  bool    false  "游标分页时是否统计总数"
+// @Param        sortBy    query  string  false  "排序字段: publishTime(默认)、lastModifyTime（最近修改或同步的时间）、visitCount、title、createTime，或声明了类型的扩展字段"
+// @Param        order     query  string  false  "排序方向: desc(默认)、asc"
+// @Param        fields    query  string  false  "只返回这些字段，逗号分隔，如 title,publishTime,visitUrl；articleId 始终返回"
+// @Param        excludeFields query string false "不返回这些字段，逗号分隔，如 content,attachment"
+// @Param        contentFormat query string false "正文格式: html(默认)、text（纯文本）"
+// @Param        absoluteUrls  query bool   false "为 true 时将正文 src/href、封面图、附件中的站内地址补全为站点域名下的绝对地址"
+// @Param        excerpt       query int    false "summary 为空时从正文生成该长度（字符）的摘要，最大 1000"
+// @Param        body      body   ArticleQuery  false  "仅 POST：JSON 请求体，数组参数可直接传数组，filter 支持 must/should/mustNot 嵌套条件"
 // @Success      200  {object}  util.Response
+// @Failure      400  {object}  util.Response
 // @Router       /api/v1/webplus/getArticles [get]
 // @Router       /api/v1/webplus/getArticles [post]
 func (h *Handler) GetArticles(c *gin.Context) {
-	columnIdStr := util.GetParam(c, "columnId")
+	columnIdStr := articleColumnParam(c)
 	var siteIdStr string
 	//如果同时传columnId和siteId，则只看columnId
 	if columnIdStr == "" {
-		siteIdStr = util.GetParam(c, "siteId")
+		siteIdStr = articleSiteParam(c)
 	}
 	articleIdStr := util.GetParam(c, "articleId")
 
 	title := util.GetParam(c, "title")
-	startTimeStr := util.GetParam(c, "startTime")
-	endTimeStr := util.GetParam(c, "endTime")
 
 	loc, _ := time.LoadLocation("Asia/Shanghai") //统一为北京时间
-	timeFormats := []string{
-		time.RFC3339,
-		"2006-01-02T15:04:05 -07:00",
-		"2006-01-02T15:04:05-07:00",
-		"2006-01-02 15:04:05",
-		time.RFC3339Nano,
-		"2006-01-02",
+	startTime, endTime, err := parseTimeRange(c)
+	if err != nil {
+		util.Err(c, err)
+		return
 	}
 
-	var startTime, endTime *time.Time
-	if startTimeStr != "" {
-		var parsed time.Time
-		var err error
-		for _, f := range timeFormats {
-			if parsed, err = time.Parse(f, startTimeStr); err == nil {
-				parsed = parsed.In(loc)
-				if f == "2006-01-02" {
-					parsed = time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, loc)
-				}
-				break
-			}
-		}
-		if err != nil {
-			util.Err(c, fmt.Errorf("invalid startTime: %s", startTimeStr))
-			return
-		}
-		startTime = &parsed
+	page, pageSize := parsePaging(c)
+
+	// 排序方式，只允许白名单字段；JSON 请求体可以用 sort 对象指定
+	sortBy, order := util.GetParam(c, "sortBy"), util.GetParam(c, "order")
+	var bodySort ArticleQuerySort
+	if ok, err := util.BindJSONParam(c, "sort", &bodySort); err != nil {
+		util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+		return
+	} else if ok && sortBy == "" {
+		sortBy, order = bodySort.By, bodySort.Order
 	}
-	if endTimeStr != "" {
-		var parsed time.Time
-		var err error
-		for _, f := range timeFormats {
-			if parsed, err = time.Parse(f, endTimeStr); err == nil {
-				parsed = parsed.In(loc)
-				if f == "2006-01-02" {
-					parsed = time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 23, 59, 59, 0, loc)
-				}
-				break
-			}
-		}
-		if err != nil {
-			util.Err(c, fmt.Errorf("invalid endTime: %s", endTimeStr))
-			return
-		}
-		endTime = &parsed
+	sortSpec, err := parseArticleSort(sortBy, order, h.extFields)
+	if err != nil {
+		util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+		return
 	}
 
-	pageSizeStr := util.GetParam(c, "pageSize")
-	if pageSizeStr == "" {
-		pageSizeStr = "20"
+	// 返回字段，只查询需要的列（如列表页不需要 content）
+	proj, err := h.parseFieldProjection(c)
+	if err != nil {
+		util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+		return
 	}
-	pageSize, _ := strconv.Atoi(pageSizeStr)
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+
+	// 游标分页：传入 cursor 参数即启用（首页传空值），默认不统计总数
+	cursorMode := util.HasParam(c, "cursor")
+	var cursor *articleCursor
+	if cursorStr := util.GetParam(c, "cursor"); cursorStr != "" {
+		cur, err := decodeArticleCursor(cursorStr)
+		if err != nil {
+			util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+			return
+		}
+		cursor = cur
 	}
-	pageStr := util.GetParam(c, "page")
-	if pageStr == "" {
-		pageStr = "1"
-	}
-	page, _ := strconv.Atoi(pageStr)
-	if page < 1 {
-		page = 1
+	withTotal := !cursorMode
+	if cursorMode {
+		withTotal, _ = strconv.ParseBool(util.GetParam(c, "withTotal"))
 	}
 
 	targetDB := db.GetTargetDB()
@@ -132,6 +125,16 @@ func (h *Handler) GetArticles(c *gin.Context) {
 		}
 		filterColumnId = first
 
+		// 包含子栏目：按 T_COLUMN.path 展开为整棵子树，文章通过 DISTINCT 去重
+		if includeChildren, _ := strconv.ParseBool(util.GetParam(c, "includeChildren")); includeChildren {
+			expanded, err := expandColumnIds(targetDB, validColumnIds)
+			if err != nil {
+				util.Err(c, err)
+				return
+			}
+			validColumnIds = expanded
+		}
+
 		if err := targetDB.Table(models.TableNameArticleDynamic).
 			Select("DISTINCT articleId").
 			Where("columnId IN ?", validColumnIds).
@@ -167,20 +170,6 @@ func (h *Handler) GetArticles(c *gin.Context) {
 	}
 
 	// 2. 构建 article_static 查询
-	type ArticleRow struct {
-		ArticleId      int64      `gorm:"column:articleId"`
-		Title          string     `gorm:"column:title"`
-		Summary        string     `gorm:"column:summary"`
-		CreatorName    string     `gorm:"column:creatorName"`
-		PublishTime    *time.Time `gorm:"column:publishTime"`
-		LastModifyTime *time.Time `gorm:"column:lastModifyTime"`
-		FirstImgPath   string     `gorm:"column:firstImgPath"`
-		Content        string     `gorm:"column:content"`
-		VisitUrl       string     `gorm:"column:visitUrl"`
-		VisitCount     int        `gorm:"column:visitCount"`
-		Keywords       string     `gorm:"column:keywords"`
-	}
-
 	query := targetDB.Table(models.TableNameArticleStatic)
 
 	// 按栏目过滤
@@ -209,13 +198,30 @@ func (h *Handler) GetArticles(c *gin.Context) {
 	}
 
 	for _, field := range h.cfg.Search.FuzzyField {
-		keyword := util.GetParam(c, field) // 自动用字段名作为 query 参数名
+		// 声明了类型的扩展字段按类型过滤，不再模糊匹配
+		if _, typed := h.extFields.typeOf(strings.ToLower(field)); typed {
+			continue
+		}
+		keyword := util.GetParam(c, field) // 自动用字段名作为 query 参数名，也可以用配置的语义名称
+		for _, name := range h.extFields.namesOf(strings.ToLower(field)) {
+			if keyword != "" {
+				break
+			}
+			keyword = util.GetParam(c, name)
+		}
 		if keyword != "" {
 			likeValue := "%" + strings.TrimSpace(keyword) + "%"
 			query = query.Where(fmt.Sprintf("%s LIKE ?", field), likeValue)
 		}
 	}
 
+	// 扩展字段的精确匹配、IN、范围过滤
+	query, err = h.extFields.applyExtFilters(c, targetDB, query)
+	if err != nil {
+		util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+		return
+	}
+
 	// 时间范围过滤
 	if startTime != nil {
 		query = query.Where("publishTime >= ?", *startTime)
@@ -224,25 +230,73 @@ func (h *Handler) GetArticles(c *gin.Context) {
 		query = query.Where("publishTime <= ?", *endTime)
 	}
 
+	// JSON 请求体中的 filter（must/should/mustNot），与上面的条件按 AND 组合
+	var filter ArticleFilter
+	if ok, err := util.BindJSONParam(c, "filter", &filter); err != nil {
+		util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+		return
+	} else if ok {
+		cond, args, err := h.compileArticleFilter(targetDB, filter)
+		if err != nil {
+			util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+			return
+		}
+		if cond != "" {
+			query = query.Where(cond, args...)
+		}
+	}
+
+	// 限制在调用方可访问的范围内
+	query = scopeArticleQuery(c, targetDB, query, "articleId")
+
 	// 3. 统计总数（不分页）
 	var total int64
-	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
-		util.Err(c, fmt.Errorf("统计文章总数失败: %v", err))
-		return
+	if withTotal {
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			util.Err(c, fmt.Errorf("统计文章总数失败: %v", err))
+			return
+		}
 	}
 
-	// 排序 + 分页（基于 page/pageSize）
-	offset := (page - 1) * pageSize
-	query = query.Order("publishTime DESC, articleId DESC").Offset(offset).Limit(pageSize)
+	// 排序 + 分页
+	query = sortSpec.joinExtSort(query).Order(sortSpec.orderBy())
+	if cursorMode {
+		// 基于游标：从上一页最后一条之后开始，多取一条用于判断是否还有下一页
+		if cursor != nil {
+			cond, args, err := cursor.whereAfter(sortSpec, loc)
+			if err != nil {
+				util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+				return
+			}
+			query = query.Where(cond, args...)
+		}
+		query = query.Limit(pageSize + 1)
+	} else {
+		// 基于 page/pageSize
+		offset := (page - 1) * pageSize
+		query = query.Offset(offset).Limit(pageSize)
+	}
 
-	var rows []ArticleRow
-	if err := query.Scan(&rows).Error; err != nil {
+	var rows []articleRow
+	if err := query.Select(proj.selectColumns(sortSpec)).Scan(&rows).Error; err != nil {
 		util.Err(c, fmt.Errorf("查询文章列表失败: %v", err))
 		return
 	}
 
 	// 是否还有下一页
-	hasNext := int64(page*pageSize) < total
+	var hasNext bool
+	var nextCursor string
+	if cursorMode {
+		hasNext = len(rows) > pageSize
+		if hasNext {
+			rows = rows[:pageSize]
+		}
+		if hasNext && len(rows) > 0 {
+			nextCursor = encodeArticleCursor(newArticleCursor(sortSpec, rows[len(rows)-1]))
+		}
+	} else {
+		hasNext = int64(page*pageSize) < total
+	}
 
 	// 3. 批量查询栏目数据并组装 Id/Name，并查询附件
 	var articleIDs []int64
@@ -250,42 +304,223 @@ func (h *Handler) GetArticles(c *gin.Context) {
 		articleIDs = append(articleIDs, r.ArticleId)
 	}
 
-	columnMap := make(map[int64][]models.Column)
-	attachMap := make(map[int64][]models.Attachment)
-	if len(articleIDs) > 0 {
-		var colRows []models.Column
-		if err := targetDB.Table(models.TableNameArticleDynamic).
-			Select("articleId, columnId, columnName,siteId, siteName,Url as url").
-			Where("articleId IN ?", articleIDs).
-			Scan(&colRows).Error; err != nil {
-			util.Err(c, fmt.Errorf("查询文章栏目和站点失败: %v", err))
-			return
+	columnMap, attachMap, err := loadArticleRelations(targetDB, articleIDs)
+	if err != nil {
+		util.Err(c, err)
+		return
+	}
+	scopeColumnMap(c, columnMap)
+
+	// 4. 组装响应列表（保持 SQL 排序结果的顺序）
+	list := h.buildArticleItems(rows, columnMap, attachMap, filterColumnId, proj)
+
+	pagination := gin.H{
+		"page":     page,
+		"pageSize": pageSize,
+		"hasNext":  hasNext,
+		"total":    total,
+	}
+	if cursorMode {
+		pagination = gin.H{
+			"pageSize":   pageSize,
+			"hasNext":    hasNext,
+			"nextCursor": nextCursor,
 		}
-		for _, cr := range colRows {
-			columnMap[cr.ArticleId] = append(columnMap[cr.ArticleId], cr)
+		if withTotal {
+			pagination["total"] = total
 		}
+	}
+
+	util.Ok(c, gin.H{
+		"found":      len(list) > 0,
+		"items":      list,
+		"pagination": pagination,
+	})
+}
+
+// GetArticle 获取单篇文章详情
+// @Summary      获取文章详情
+// @Description  按文章ID获取完整文章信息，包括正文、所属栏目、附件和扩展字段
+// @Tags         articles
+// @Produce      json
+// @Param        articleId path   string  true   "文章ID"
+// @Param        columnId  query  string  false  "栏目ID，传入时使用该栏目的访问地址作为 visitUrl"
+// @Param        contentFormat query string false "正文格式: html(默认)、text（纯文本）"
+// @Param        absoluteUrls  query bool   false "为 true 时将正文 src/href、封面图、附件中的站内地址补全为站点域名下的绝对地址"
+// @Param        excerpt       query int    false "summary 为空时从正文生成该长度（字符）的摘要，最大 1000"
+// @Success      200  {object}  util.Response{data=ArticleDetail}
+// @Failure      400  {object}  util.Response
+// @Failure      404  {object}  util.Response
+// @Router       /api/v1/webplus/getArticle/{articleId} [get]
+func (h *Handler) GetArticle(c *gin.Context) {
+	articleIdStr := strings.TrimSpace(c.Param("articleId"))
+	articleId, err := strconv.ParseInt(articleIdStr, 10, 64)
+	if err != nil {
+		util.Err(c, gin.H{"error": fmt.Sprintf("articleId 必须为数字: %s", articleIdStr), "code": http.StatusBadRequest})
+		return
+	}
+	columnIdStr := util.GetParam(c, "columnId")
+	contentOpts, err := parseContentOptions(c)
+	if err != nil {
+		util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+		return
+	}
 
-		var attRows []models.ArticleAttachment
-		if err := targetDB.Table(models.TableNameArticleAttachment).
-			Select("articleId, name, path").
-			Where("articleId IN ?", articleIDs).
-			Scan(&attRows).Error; err != nil {
-			util.Err(c, fmt.Errorf("查询文章附件失败: %v", err))
+	targetDB := db.GetTargetDB()
+	if targetDB == nil {
+		util.Err(c, fmt.Errorf("targetDB 未初始化"))
+		return
+	}
+
+	var row models.ArticleStatic
+	if err := scopeArticleQuery(c, targetDB, targetDB.Table(models.TableNameArticleStatic), "articleId").
+		Where("articleId = ?", articleId).
+		Take(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			util.Err(c, gin.H{"error": fmt.Sprintf("文章不存在: %s", articleIdStr), "code": http.StatusNotFound})
 			return
 		}
-		for _, ar := range attRows {
-			attachMap[ar.ArticleId] = append(attachMap[ar.ArticleId], models.Attachment{
-				Name: ar.Name,
-				Path: ar.Path,
-			})
-		}
+		util.Err(c, fmt.Errorf("查询文章详情失败: %v", err))
+		return
+	}
+
+	columnMap, attachMap, err := loadArticleRelations(targetDB, []int64{articleId})
+	if err != nil {
+		util.Err(c, err)
+		return
+	}
+	scopeColumnMap(c, columnMap)
+
+	detail := ArticleDetail{
+		ArticleId:      strconv.FormatInt(articleId, 10),
+		SiteId:         row.CreateSiteId,
+		Title:          row.Title,
+		ShortTitle:     row.ShortTitle,
+		AuxiliaryTitle: row.AuxiliaryTitle,
+		CreatorName:    row.CreatorName,
+		Summary:        row.Summary,
+		PublishTime:    row.PublishTime,
+		LastModifyTime: row.LastModifyTime,
+		PublisherName:  row.PublisherName,
+		PublishOrgName: row.PublishOrgName,
+		FirstImgPath:   row.FirstImgPath,
+		VisitUrl:       row.VisitUrl,
+		VisitCount:     row.VisitCount,
+		Keywords:       row.Keywords,
+		Content:        row.Content,
+		Attachment:     attachMap[articleId],
+		ColumnInfo:     make([]ArticleColumnInfo, 0),
+		ArticleFields:  row.ArticleFields,
+	}
+	if detail.Attachment == nil {
+		detail.Attachment = []models.Attachment{}
+	}
+
+	cols := columnMap[articleId]
+	sort.Slice(cols, func(i, j int) bool { return cols[i].ColumnId < cols[j].ColumnId })
+	for _, cRow := range cols {
+		colId := strconv.FormatInt(int64(cRow.ColumnId), 10)
+		detail.ColumnInfo = append(detail.ColumnInfo, ArticleColumnInfo{
+			ColumnId:   colId,
+			ColumnName: cRow.ColumnName,
+			SiteId:     cRow.SiteId,
+			SiteName:   cRow.SiteName,
+			Url:        cRow.Url,
+		})
+		// 指定栏目时使用该栏目的 URL 覆盖 visitUrl，与列表接口保持一致
+		if columnIdStr != "" && colId == columnIdStr && cRow.Url != "" {
+			detail.VisitUrl = cRow.Url
+		}
+	}
+
+	// 只有详情输出 Last-Modified：列表中文章被删除或移入本页不会让最晚修改时间变化，只能依赖 ETag
+	if row.LastModifyTime != nil {
+		util.SetLastModified(c, row.LastModifyTime)
+	} else {
+		util.SetLastModified(c, row.PublishTime)
+	}
+	contentOpts.applyDetail(&detail)
+
+	// 与列表接口一致，只返回配置的 EnabledFields
+	b, err := json.Marshal(detail)
+	if err != nil {
+		util.Err(c, fmt.Errorf("序列化文章详情失败: %v", err))
+		return
+	}
+	var item gin.H
+	if err := json.Unmarshal(b, &item); err != nil {
+		util.Err(c, fmt.Errorf("序列化文章详情失败: %v", err))
+		return
 	}
+	h.enabledFieldProjection().filter(item, detail.SiteId)
+	util.Ok(c, h.extFields.rename(detail.SiteId, item))
+}
+
+// articleRow article_static 列表查询结果
+type articleRow struct {
+	ArticleId      int64      `gorm:"column:articleId"`
+	CreateSiteId   string     `gorm:"column:createSiteId"`
+	Title          string     `gorm:"column:title"`
+	Summary        string     `gorm:"column:summary"`
+	CreatorName    string     `gorm:"column:creatorName"`
+	PublishTime    *time.Time `gorm:"column:publishTime"`
+	LastModifyTime *time.Time `gorm:"column:lastModifyTime"`
+	FirstImgPath   string     `gorm:"column:firstImgPath"`
+	Content        string     `gorm:"column:content"`
+	VisitUrl       string     `gorm:"column:visitUrl"`
+	VisitCount     int        `gorm:"column:visitCount"`
+	Keywords       string     `gorm:"column:keywords"`
+	CreateTime     string     `gorm:"column:createTime"`
+	models.ArticleFields
+	// 按扩展字段排序时的排序值
+	ExtSortStr  *string    `gorm:"column:extSortStr"`
+	ExtSortNum  *int64     `gorm:"column:extSortNum"`
+	ExtSortTime *time.Time `gorm:"column:extSortTime"`
+}
 
-	// 4. 组装为 ArticleInfo
-	allArticles := make([]models.ArticleInfo, 0, len(rows))
+// loadArticleRelations 批量查询文章的栏目/站点信息和附件，按 articleId 分组返回
+func loadArticleRelations(targetDB *gorm.DB, articleIDs []int64) (map[int64][]models.Column, map[int64][]models.Attachment, error) {
+	columnMap := make(map[int64][]models.Column)
+	attachMap := make(map[int64][]models.Attachment)
+	if len(articleIDs) == 0 {
+		return columnMap, attachMap, nil
+	}
+
+	var colRows []models.Column
+	if err := targetDB.Table(models.TableNameArticleDynamic).
+		Select("articleId, columnId, columnName,siteId, siteName,Url as url").
+		Where("articleId IN ?", articleIDs).
+		Scan(&colRows).Error; err != nil {
+		return nil, nil, fmt.Errorf("查询文章栏目和站点失败: %v", err)
+	}
+	for _, cr := range colRows {
+		columnMap[cr.ArticleId] = append(columnMap[cr.ArticleId], cr)
+	}
+
+	var attRows []models.ArticleAttachment
+	if err := targetDB.Table(models.TableNameArticleAttachment).
+		Select("articleId, name, path").
+		Where("articleId IN ?", articleIDs).
+		Scan(&attRows).Error; err != nil {
+		return nil, nil, fmt.Errorf("查询文章附件失败: %v", err)
+	}
+	for _, ar := range attRows {
+		attachMap[ar.ArticleId] = append(attachMap[ar.ArticleId], models.Attachment{
+			Name: ar.Name,
+			Path: ar.Path,
+		})
+	}
+	return columnMap, attachMap, nil
+}
+
+// buildArticleItems 将 article_static 查询结果与栏目、附件组装为响应列表，保持 rows 的顺序
+// filterColumnId 不为空时，使用该栏目的 URL 覆盖 visitUrl；proj 决定返回哪些字段
+func (h *Handler) buildArticleItems(rows []articleRow, columnMap map[int64][]models.Column, attachMap map[int64][]models.Attachment, filterColumnId string, proj *fieldProjection) []gin.H {
+	list := make([]gin.H, 0, len(rows))
 	for _, r := range rows {
 		a := models.ArticleInfo{
 			ArticleId:      strconv.FormatInt(r.ArticleId, 10),
+			SiteId:         r.CreateSiteId,
 			Title:          r.Title,
 			Summary:        r.Summary,
 			CreatorName:    r.CreatorName,
@@ -296,46 +531,24 @@ func (h *Handler) GetArticles(c *gin.Context) {
 			Content:        r.Content,
 			VisitCount:     r.VisitCount,
 			Keywords:       r.Keywords,
+			ArticleFields:  r.ArticleFields,
 		}
 
-		if cols, ok := columnMap[r.ArticleId]; ok {
-			// 保持按 columnId 升序
-			sort.Slice(cols, func(i, j int) bool { return cols[i].ColumnId < cols[j].ColumnId })
-			for _, cRow := range cols {
-				a.ColumnId = append(a.ColumnId, strconv.FormatInt(int64(cRow.ColumnId), 10))
-				a.ColumnName = append(a.ColumnName, cRow.ColumnName)
-			}
+		cols := columnMap[r.ArticleId]
+		// 保持按 columnId 升序
+		sort.Slice(cols, func(i, j int) bool { return cols[i].ColumnId < cols[j].ColumnId })
+		for _, cRow := range cols {
+			a.ColumnId = append(a.ColumnId, strconv.FormatInt(int64(cRow.ColumnId), 10))
+			a.ColumnName = append(a.ColumnName, cRow.ColumnName)
 		}
 		if atts, ok := attachMap[r.ArticleId]; ok {
 			a.Attachment = atts
 		}
-		allArticles = append(allArticles, a)
-	}
-
-	// 排序（时间倒序）
-	sort.Slice(allArticles, func(i, j int) bool {
-		if allArticles[i].PublishTime == nil && allArticles[j].PublishTime == nil {
-			return allArticles[i].ArticleId > allArticles[j].ArticleId
-		}
-		if allArticles[i].PublishTime == nil {
-			return false
-		}
-		if allArticles[j].PublishTime == nil {
-			return true
-		}
-		if allArticles[i].PublishTime.Equal(*allArticles[j].PublishTime) {
-			return allArticles[i].ArticleId > allArticles[j].ArticleId
-		}
-		return allArticles[i].PublishTime.After(*allArticles[j].PublishTime)
-	})
 
-	list := make([]gin.H, len(allArticles))
-	for i, a := range allArticles {
-		item := h.buildArticleResponse(a)
+		item := h.buildArticleResponse(a, proj)
 
 		// 组装栏目数组 [{columnId,columnName,url},...]
-		articleIDInt, _ := strconv.ParseInt(a.ArticleId, 10, 64)
-		if cols, ok := columnMap[articleIDInt]; ok {
+		if len(cols) > 0 && proj.allows("columninfo") {
 			columnsArr := make([]gin.H, 0, len(cols))
 			for _, cRow := range cols {
 				columnsArr = append(columnsArr, gin.H{
@@ -349,7 +562,7 @@ func (h *Handler) GetArticles(c *gin.Context) {
 			item["columnInfo"] = columnsArr
 
 			// 如果按 columnId 精确过滤，优先使用对应栏目的 URL 覆盖 visitUrl
-			if filterColumnId != "" {
+			if filterColumnId != "" && proj.allows("visiturl") {
 				for _, cRow := range cols {
 					if strconv.FormatInt(int64(cRow.ColumnId), 10) == filterColumnId && cRow.Url != "" {
 						item["visitUrl"] = cRow.Url
@@ -359,19 +572,121 @@ func (h *Handler) GetArticles(c *gin.Context) {
 			}
 		}
 
-		list[i] = item
+		list = append(list, item)
 	}
+	return list
+}
 
-	util.Ok(c, gin.H{
-		"found": len(allArticles) > 0,
-		"items": list,
-		"pagination": gin.H{
-			"page":     page,
-			"pageSize": pageSize,
-			"hasNext":  hasNext,
-			"total":    total,
-		},
-	})
+// parseTimeRange 解析 startTime/endTime 参数（北京时间），仅传日期时分别取当天的开始和结束
+func parseTimeRange(c *gin.Context) (*time.Time, *time.Time, error) {
+	startTimeStr := util.GetParam(c, "startTime")
+	endTimeStr := util.GetParam(c, "endTime")
+
+	var startTime, endTime *time.Time
+	if startTimeStr != "" {
+		parsed, err := parseQueryTime(startTimeStr, false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid startTime: %s", startTimeStr)
+		}
+		startTime = &parsed
+	}
+	if endTimeStr != "" {
+		parsed, err := parseQueryTime(endTimeStr, true)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid endTime: %s", endTimeStr)
+		}
+		endTime = &parsed
+	}
+	return startTime, endTime, nil
+}
+
+// parseQueryTime 解析请求中的时间（北京时间），仅传日期时取当天开始，endOfDay 为 true 时取当天结束
+func parseQueryTime(s string, endOfDay bool) (time.Time, error) {
+	loc, _ := time.LoadLocation("Asia/Shanghai") //统一为北京时间
+	timeFormats := []string{
+		time.RFC3339,
+		"2006-01-02T15:04:05 -07:00",
+		"2006-01-02T15:04:05-07:00",
+		"2006-01-02 15:04:05",
+		time.RFC3339Nano,
+		"2006-01-02",
+	}
+
+	var parsed time.Time
+	var err error
+	for _, f := range timeFormats {
+		if parsed, err = time.Parse(f, s); err == nil {
+			parsed = parsed.In(loc)
+			if f == "2006-01-02" {
+				if endOfDay {
+					parsed = time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 23, 59, 59, 0, loc)
+				} else {
+					parsed = time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, loc)
+				}
+			}
+			break
+		}
+	}
+	return parsed, err
+}
+
+// articleColumnParam 栏目ID参数，JSON 请求体中也可以使用 columnIds 数组
+func articleColumnParam(c *gin.Context) string {
+	if s := util.GetParam(c, "columnId"); s != "" {
+		return s
+	}
+	return util.GetParam(c, "columnIds")
+}
+
+// articleSiteParam 站点ID参数，JSON 请求体中也可以使用 siteIds 数组
+func articleSiteParam(c *gin.Context) string {
+	if s := util.GetParam(c, "siteId"); s != "" {
+		return s
+	}
+	return util.GetParam(c, "siteIds")
+}
+
+// parsePaging 解析 page/pageSize 参数，pageSize 默认 20、最大 100
+func parsePaging(c *gin.Context) (int, int) {
+	pageSize, _ := strconv.Atoi(util.GetParam(c, "pageSize"))
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	page, _ := strconv.Atoi(util.GetParam(c, "page"))
+	if page < 1 {
+		page = 1
+	}
+	return page, pageSize
+}
+
+// expandColumnIds 将栏目ID展开为包含其全部子孙栏目的ID列表（去重，保留原有ID）
+// T_COLUMN.path 记录祖先栏目ID，形如 /1/23/45/，子孙栏目的 path 中必然包含该栏目ID
+func expandColumnIds(targetDB *gorm.DB, columnIds []int64) ([]int64, error) {
+	if len(columnIds) == 0 {
+		return columnIds, nil
+	}
+	query := targetDB.Table(models.TableNameTColumn).Select("id")
+	cond := targetDB.Where("id IN ?", columnIds)
+	for _, id := range columnIds {
+		cond = cond.Or("path LIKE ?", fmt.Sprintf("%%/%d/%%", id)).
+			Or("path LIKE ?", fmt.Sprintf("%%/%d", id))
+	}
+	var descendants []int64
+	if err := query.Where(cond).Pluck("id", &descendants).Error; err != nil {
+		return nil, fmt.Errorf("查询子栏目失败: %v", err)
+	}
+
+	seen := make(map[int64]bool, len(columnIds)+len(descendants))
+	result := make([]int64, 0, len(columnIds)+len(descendants))
+	for _, ids := range [][]int64{columnIds, descendants} {
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				result = append(result, id)
+			}
+		}
+	}
+	return result, nil
 }
 
 // parseIDList 将逗号分隔的 ID 字符串解析为 int64 列表，并返回第一个合法 ID 的原始字符串
@@ -394,8 +709,11 @@ func parseIDList(s string) ([]int64, string) {
 	return result, firstRaw
 }
 
-// buildArticleResponse 根据配置构建文章响应数据
-func (h *Handler) buildArticleResponse(a models.ArticleInfo) gin.H {
+// buildArticleResponse 构建文章响应数据，按 proj（配置与请求参数的交集）过滤字段
+func (h *Handler) buildArticleResponse(a models.ArticleInfo, proj *fieldProjection) gin.H {
+	// 按请求的输出方式改写正文、摘要和站内地址
+	proj.content.applyArticle(&a)
+
 	// 构建完整的响应数据
 	fullData := gin.H{
 		"articleId":      a.ArticleId,
@@ -412,31 +730,11 @@ func (h *Handler) buildArticleResponse(a models.ArticleInfo) gin.H {
 		"keywords":       a.Keywords,
 	}
 
-	// 注入扩展字段
+	// 注入扩展字段，按文章创建站点的配置使用语义名称
 	injectArticleFields(fullData, a.ArticleFields)
+	proj.filter(fullData, a.SiteId)
 
-	// 如果没有配置字段过滤，返回所有字段
-	if h.cfg.ResponseFields == nil || len(h.cfg.ResponseFields.EnabledFields) == 0 {
-		return fullData
-	}
-
-	// 根据配置过滤字段
-	enabledFields := make(map[string]bool)
-	for _, field := range h.cfg.ResponseFields.EnabledFields {
-		enabledFields[strings.ToLower(field)] = true
-	}
-
-	// 构建过滤后的响应数据
-	filteredData := make(gin.H)
-	for key, value := range fullData {
-		// 字段名转换为小写进行匹配
-		keyLower := strings.ToLower(key)
-		if enabledFields[keyLower] {
-			filteredData[key] = value
-		}
-	}
-
-	return filteredData
+	return h.extFields.rename(a.SiteId, fullData)
 }
 
 func injectArticleFields(target gin.H, fields models.ArticleFields) {
@@ -461,22 +759,7 @@ func (h *Handler) GetSites(c *gin.Context) {
 	siteIdStr := util.GetParam(c, "siteId")
 	name := util.GetParam(c, "name")
 
-	pageSizeStr := util.GetParam(c, "pageSize")
-	if pageSizeStr == "" {
-		pageSizeStr = "20"
-	}
-	pageSize, _ := strconv.Atoi(pageSizeStr)
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
-	}
-	pageStr := util.GetParam(c, "page")
-	if pageStr == "" {
-		pageStr = "1"
-	}
-	page, _ := strconv.Atoi(pageStr)
-	if page < 1 {
-		page = 1
-	}
+	page, pageSize := parsePaging(c)
 
 	targetDB := db.GetTargetDB()
 	if targetDB == nil {
@@ -485,21 +768,8 @@ func (h *Handler) GetSites(c *gin.Context) {
 	}
 
 	// 构建查询
-	query := targetDB.Table(models.TableNameTSite)
-
-	// 站点ID过滤
-	if siteIdStr != "" {
-		validSiteIds, _ := parseIDList(siteIdStr)
-		if len(validSiteIds) > 0 {
-			query = query.Where("ID IN ?", validSiteIds)
-		}
-	}
-
-	// 名称模糊搜索
-	if name != "" {
-		like := "%" + name + "%"
-		query = query.Where("NAME LIKE ?", like)
-	}
+	validSiteIds, _ := parseIDList(siteIdStr)
+	query := siteListQuery(c, targetDB, validSiteIds, name)
 
 	// 统计总数
 	var total int64
@@ -521,161 +791,8 @@ func (h *Handler) GetSites(c *gin.Context) {
 	// 是否还有下一页
 	hasNext := int64(page*pageSize) < total
 
-	// 批量查询 T_PUBLISHSITE 表，获取已发布的站点ID（deleted = 0）和完整的发布记录
-	publishedSiteIds := make(map[int]bool)
-	siteToPublishSiteMap := make(map[int]*models.TPublishSite)     // siteId -> TPublishSite（当前站点的发布记录）
-	publishSiteIdToRecordMap := make(map[int]*models.TPublishSite) // publishSiteId -> TPublishSite（所有发布记录，用于查找父记录）
-	if len(sites) > 0 {
-		// 收集所有站点ID
-		siteIds := make([]int, 0, len(sites))
-		for _, s := range sites {
-			siteIds = append(siteIds, s.Id)
-		}
-
-		// 查询 T_PUBLISHSITE 表，找出 siteId 存在且 deleted = 0 的记录，获取完整信息
-		var publishSites []models.TPublishSite
-		if err := targetDB.Table(models.TableNameTPubSite).
-			Where("siteId IN ? AND deleted = ?", siteIds, 0).
-			Find(&publishSites).Error; err == nil {
-			// 构建映射
-			for i := range publishSites {
-				ps := &publishSites[i]
-				publishedSiteIds[ps.SiteId] = true
-				siteToPublishSiteMap[ps.SiteId] = ps
-				publishSiteIdToRecordMap[ps.Id] = ps
-			}
-		}
-
-		// 收集所有需要的 parentId（用于查找父发布记录）
-		parentPublishSiteIds := make([]int, 0)
-		parentIdSet := make(map[int]bool)
-		for _, ps := range publishSites {
-			if ps.ParentId > 0 && !parentIdSet[ps.ParentId] {
-				parentPublishSiteIds = append(parentPublishSiteIds, ps.ParentId)
-				parentIdSet[ps.ParentId] = true
-			}
-		}
-
-		// 查询父发布记录（通过 parentId 找到父站点的发布记录）
-		if len(parentPublishSiteIds) > 0 {
-			var parentPublishSites []models.TPublishSite
-			if err := targetDB.Table(models.TableNameTPubSite).
-				Where("id IN ? AND deleted = ?", parentPublishSiteIds, 0).
-				Find(&parentPublishSites).Error; err == nil {
-				for i := range parentPublishSites {
-					pps := &parentPublishSites[i]
-					publishSiteIdToRecordMap[pps.Id] = pps
-				}
-			}
-		}
-	}
-
-	// 批量查询父站点信息（用于没有 domainName 的站点）
-	parentSiteMap := make(map[int]models.TSite) // siteId -> TSite
-	if len(siteToPublishSiteMap) > 0 {
-		// 收集所有需要的父站点 siteId
-		parentSiteIds := make([]int, 0)
-		parentSiteIdSet := make(map[int]bool)
-		for _, ps := range siteToPublishSiteMap {
-			if ps.ParentId > 0 {
-				// 通过 parentId 找到父发布记录
-				if parentPublishSite, exists := publishSiteIdToRecordMap[ps.ParentId]; exists {
-					parentSiteId := parentPublishSite.SiteId
-					if parentSiteId > 0 && !parentSiteIdSet[parentSiteId] {
-						parentSiteIds = append(parentSiteIds, parentSiteId)
-						parentSiteIdSet[parentSiteId] = true
-					}
-				}
-			}
-		}
-
-		// 批量查询父站点
-		if len(parentSiteIds) > 0 {
-			var parentSites []models.TSite
-			if err := targetDB.Table(models.TableNameTSite).
-				Where("ID IN ?", parentSiteIds).
-				Find(&parentSites).Error; err == nil {
-				for _, ps := range parentSites {
-					parentSiteMap[ps.Id] = ps
-				}
-			}
-		}
-	}
-
-	// 转换为响应格式
-	list := make([]SiteInfo, len(sites))
-	for i, s := range sites {
-		// 根据 T_PUBLISHSITE 表判断是否已发布
-		status := 0
-		if publishedSiteIds[s.Id] {
-			status = 1
-		}
-
-		// 处理域名：已发布的站点必须有 URL
-		siteUrl := ""
-		if status == 1 {
-			// 情况1：站点有自己的 domainName
-			if s.DomainName != "" {
-				re := regexp.MustCompile(`[,，]+`)
-				parts := re.Split(s.DomainName, -1)
-				for _, part := range parts {
-					trimmed := strings.TrimSpace(part)
-					if trimmed != "" {
-						siteUrl = trimmed
-						break
-					}
-				}
-			} else {
-				// 情况2：通过 T_PUBLISHSITE 的 parentId 找到父站点的 domainName + 自己的 DummyName
-				if currentPublishSite, hasPublishSite := siteToPublishSiteMap[s.Id]; hasPublishSite {
-					if currentPublishSite.ParentId > 0 {
-						// 通过 parentId 找到父发布记录
-						if parentPublishSite, exists := publishSiteIdToRecordMap[currentPublishSite.ParentId]; exists {
-							// 获取父发布记录的 siteId
-							parentSiteId := parentPublishSite.SiteId
-							// 查询父站点的 domainName
-							if parentSite, exists := parentSiteMap[parentSiteId]; exists {
-								// 获取父站点的第一个域名
-								if parentSite.DomainName != "" {
-									re := regexp.MustCompile(`[,，]+`)
-									parts := re.Split(parentSite.DomainName, -1)
-									var parentDomain string
-									for _, part := range parts {
-										trimmed := strings.TrimSpace(part)
-										if trimmed != "" {
-											parentDomain = trimmed
-											break
-										}
-									}
-									// 拼接父站点域名 + 自己的 DummyName
-									if parentDomain != "" && s.DummyName != "" {
-										siteUrl = parentDomain + "/" + s.DummyName
-									} else if parentDomain != "" {
-										siteUrl = parentDomain
-									}
-								}
-							}
-						}
-					}
-				}
-			}
-		}
-
-		logoURL := ""
-		if s.Logo != "" && siteUrl != "" {
-			logoPath := path.Join("/_upload", s.FilePath, s.Logo)
-			logoURL = "http://" + siteUrl + logoPath
-		}
-
-		list[i] = SiteInfo{
-			SiteId:    s.Id,
-			SiteName:  s.Name,
-			Status:    status, // 根据 T_PUBLISHSITE 表判断：存在且 deleted = 0 则为 1，否则为 0
-			SiteUrl:   siteUrl,
-			ShortName: s.ShortName,
-			Logo:      logoURL,
-		}
-	}
+	// 转换为响应格式，发布状态和域名批量查询
+	list := buildSiteInfos(targetDB, sites)
 
 	response := GetSitesResponse{
 		Found: len(sites) > 0,
@@ -701,6 +818,9 @@ func (h *Handler) GetSites(c *gin.Context) {
 // @Param        page     query  int     false  "页码，从1开始"
 // @Param        pageSize query  int     false  "每页大小"
 // @Param        name     query  string  false  "栏目名称模糊搜索"
+// @Param        showType query  string  false  "传 tree 时返回嵌套栏目树（需传 siteId，忽略分页）"
+// @Param        depth    query  int     false  "栏目树层级限制，1 表示只返回根节点，默认不限制"
+// @Param        navigation query  int   false  "栏目树按是否导航过滤，1 为导航栏目"
 // @Success      200  {object}  util.Response{data=GetColumnsResponse}
 // @Router       /api/v1/webplus/getColumns [get]
 // @Router       /api/v1/webplus/getColumns [post]
@@ -710,22 +830,7 @@ func (h *Handler) GetColumns(c *gin.Context) {
 	name := util.GetParam(c, "name")
 	showType := util.GetParam(c, "showType")
 
-	pageSizeStr := util.GetParam(c, "pageSize")
-	if pageSizeStr == "" {
-		pageSizeStr = "20"
-	}
-	pageSize, _ := strconv.Atoi(pageSizeStr)
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
-	}
-	pageStr := util.GetParam(c, "page")
-	if pageStr == "" {
-		pageStr = "1"
-	}
-	page, _ := strconv.Atoi(pageStr)
-	if page < 1 {
-		page = 1
-	}
+	page, pageSize := parsePaging(c)
 
 	sourceDB := db.GetTargetDB()
 	if sourceDB == nil {
@@ -733,37 +838,15 @@ func (h *Handler) GetColumns(c *gin.Context) {
 		return
 	}
 
-	// 构建查询
-	query := sourceDB.Table("T_COLUMN")
-
-	// 站点过滤
-	if siteIdStr != "" {
-		validSiteIds, _ := parseIDList(siteIdStr)
-		if len(validSiteIds) > 0 {
-			// 将 int64 转换为 string 进行查询（因为 TColumn.SiteId 是 string 类型）
-			siteIdStrs := make([]string, 0, len(validSiteIds))
-			for _, id := range validSiteIds {
-				siteIdStrs = append(siteIdStrs, strconv.FormatInt(id, 10))
-			}
-			query = query.Where("siteId IN ?", siteIdStrs)
-		}
-	}
-
-	// 父栏目过滤
-	if parentIdStr != "" {
-		query = query.Where("parentId = ?", parentIdStr)
-	}
-
-	// 名称模糊搜索
-	if name != "" {
-		like := "%" + name + "%"
-		query = query.Where("name LIKE ?", like)
-	}
-
 	if showType == "tree" {
-		query = query.Where("parentId = 0 AND id != 1 ")
+		h.getColumnTree(c, sourceDB)
+		return
 	}
 
+	// 构建查询
+	validSiteIds, _ := parseIDList(siteIdStr)
+	query := columnListQuery(c, sourceDB, validSiteIds, parentIdStr, name)
+
 	// 统计总数
 	var total int64
 	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
@@ -784,42 +867,8 @@ func (h *Handler) GetColumns(c *gin.Context) {
 	// 是否还有下一页
 	hasNext := int64(page*pageSize) < total
 
-	// 转换为响应格式
-	list := make([]ColumnInfo, len(columns))
-	for i := range columns {
-		//处理URL
-		col := &columns[i] // 获取指针，避免拷贝
-
-		if col.Link == "" {
-			col.Link = generateUrl(*col) // 注意：如果 generateUrl 需要值，就传 *col；如果接受指针，就传 col
-		}
-		//处理全路径
-		allPathIds := h.extractIdsFromPath(col.Path)
-		columnIdToName := make(map[int]string)
-		if len(allPathIds) > 0 {
-			var pathColumns []models.TColumn
-			if err := h.db.Table(models.TableNameTColumn).
-				Where("id IN ?", allPathIds).
-				Select("id, name").
-				Find(&pathColumns).Error; err == nil {
-				for _, pathCol := range pathColumns {
-					columnIdToName[pathCol.Id] = pathCol.Name
-				}
-			}
-		}
-		// 将数字 path 转换为中文 path
-		chinesePath := h.convertPathToChineseWithCache(col.Path, columnIdToName, col, allPathIds)
-
-		list[i] = ColumnInfo{
-			ColumnId:       col.Id,
-			ColumnName:     col.Name,
-			ParentColumnId: col.ParentId,
-			ColumnUrl:      col.Link,
-			Path:           chinesePath,
-			Sort:           col.Sort,
-			Status:         col.Navigation, //暂时按哈工大的需求来。如果是导航栏目就是显示
-		}
-	}
+	// 转换为响应格式，站点域名和栏目路径批量查询
+	list := h.buildColumnInfos(sourceDB, columns)
 
 	response := GetColumnsResponse{
 		Found: len(columns) > 0,
@@ -835,6 +884,50 @@ func (h *Handler) GetColumns(c *gin.Context) {
 	util.Ok(c, response)
 }
 
+// siteListQuery 站点列表查询：按ID、名称过滤并限制在调用方可访问的范围内，getSites 与 GraphQL 共用
+func siteListQuery(c *gin.Context, targetDB *gorm.DB, siteIds []int64, name string) *gorm.DB {
+	query := scopeSiteQuery(c, targetDB, targetDB.Table(models.TableNameTSite))
+
+	// 站点ID过滤
+	if len(siteIds) > 0 {
+		query = query.Where("ID IN ?", siteIds)
+	}
+
+	// 名称模糊搜索
+	if name != "" {
+		like := "%" + name + "%"
+		query = query.Where("NAME LIKE ?", like)
+	}
+	return query
+}
+
+// columnListQuery 栏目列表查询：按站点、父栏目、名称过滤并限制在调用方可访问的范围内，getColumns 与 GraphQL 共用
+func columnListQuery(c *gin.Context, targetDB *gorm.DB, siteIds []int64, parentId, name string) *gorm.DB {
+	query := scopeColumnQuery(c, targetDB.Table(models.TableNameTColumn))
+
+	// 站点过滤
+	if len(siteIds) > 0 {
+		// 将 int64 转换为 string 进行查询（因为 TColumn.SiteId 是 string 类型）
+		siteIdStrs := make([]string, 0, len(siteIds))
+		for _, id := range siteIds {
+			siteIdStrs = append(siteIdStrs, strconv.FormatInt(id, 10))
+		}
+		query = query.Where("siteId IN ?", siteIdStrs)
+	}
+
+	// 父栏目过滤
+	if parentId != "" {
+		query = query.Where("parentId = ?", parentId)
+	}
+
+	// 名称模糊搜索
+	if name != "" {
+		like := "%" + name + "%"
+		query = query.Where("name LIKE ?", like)
+	}
+	return query
+}
+
 // extractIdsFromPath 从 path 中提取所有 ID
 func (h *Handler) extractIdsFromPath(path string) []int {
 	if path == "" {
@@ -888,104 +981,23 @@ func (h *Handler) convertPathToChineseWithCache(path string, columnIdToName map[
 	return result
 }
 
-// getSiteDomainName 获取站点的域名，支持通过 T_PUBLISHSITE 的 parentId 查找父站点域名
-// siteId: 站点ID
-// dummyName: 可选的虚拟名称，如果提供了且站点没有自己的域名，会拼接到父站点域名后面
+// getSiteDomainName 获取站点的域名，支持通过 T_PUBLISHSITE 的 parentId 查找父站点域名，规则见 loadSiteDomains
 // 返回: 域名（不包含协议），如果找不到则返回空字符串
 func getSiteDomainName(siteId int) string {
 	targetDB := db.GetTargetDB()
 	if targetDB == nil {
 		return ""
 	}
-
-	// 查询站点信息
-	var site models.TSite
-	if err := targetDB.Table(models.TableNameTSite).Where("ID = ?", siteId).First(&site).Error; err != nil {
-		return ""
-	}
-
-	// 情况1：站点有自己的 domainName
-	if site.DomainName != "" {
-		re := regexp.MustCompile(`[,，]+`)
-		parts := re.Split(site.DomainName, -1)
-		for _, part := range parts {
-			trimmed := strings.TrimSpace(part)
-			if trimmed != "" {
-				return trimmed
-			}
-		}
-	}
-
-	// 情况2：站点没有 domainName，通过 T_PUBLISHSITE 的 parentId 查找父站点域名
-	dummyName := site.DummyName
-	var publishSite models.TPublishSite
-	if err := targetDB.Table(models.TableNameTPubSite).
-		Where("siteId = ? AND deleted = ?", siteId, 0).
-		First(&publishSite).Error; err != nil {
-		return ""
-	}
-
-	// 如果当前站点有 parentId
-	if publishSite.ParentId > 0 {
-		// 通过 parentId 找到父发布记录
-		var parentPublishSite models.TPublishSite
-		if err := targetDB.Table(models.TableNameTPubSite).
-			Where("id = ? AND deleted = ?", publishSite.ParentId, 0).
-			First(&parentPublishSite).Error; err != nil {
-			return ""
-		}
-
-		// 获取父发布记录的 siteId
-		parentSiteId := parentPublishSite.SiteId
-		if parentSiteId > 0 {
-			// 查询父站点信息
-			var parentSite models.TSite
-			if err := targetDB.Table(models.TableNameTSite).
-				Where("ID = ?", parentSiteId).
-				First(&parentSite).Error; err != nil {
-				return ""
-			}
-
-			// 获取父站点的第一个域名
-			if parentSite.DomainName != "" {
-				re := regexp.MustCompile(`[,，]+`)
-				parts := re.Split(parentSite.DomainName, -1)
-				var parentDomain string
-				for _, part := range parts {
-					trimmed := strings.TrimSpace(part)
-					if trimmed != "" {
-						parentDomain = trimmed
-						break
-					}
-				}
-
-				// 拼接父站点域名 + 自己的 DummyName（如果提供了）
-				if parentDomain != "" {
-					if dummyName != "" {
-						return parentDomain + "/" + dummyName
-					}
-					return parentDomain
-				}
-			}
-		}
-	}
-
-	return ""
+	return loadSiteDomainsById(targetDB, []int{siteId})[siteId]
 }
 
-func generateUrl(column models.TColumn) string {
-	targetDB := db.GetTargetDB()
-	if targetDB == nil {
-		return ""
-	}
-
-	// 使用可复用的函数获取站点域名
-	domainName := getSiteDomainName(column.SiteId)
+// buildColumnUrl 根据站点域名和栏目虚拟目录拼接栏目列表页地址，域名为空时返回空字符串
+func buildColumnUrl(domainName, urlName string) string {
 	if domainName == "" {
 		return ""
 	}
 
-	url := domainName + "/" + column.UrlName + "/list.htm"
+	url := domainName + "/" + urlName + "/list.htm"
 	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
 		url = "http://" + url
 	}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetHotArticles 热门文章
+// @Summary      热门文章
+// @Description  按访问量倒序返回站点或栏目中最近 N 天发布的文章，字段与 getArticles 一致
+// @Tags         articles
+// @Produce      json
+// @Param        siteId    query  string  false  "站点ID，逗号分隔"
+// @Param        columnId  query  string  false  "栏目ID，逗号分隔，同时传 siteId 时只看 columnId"
+// @Param        includeChildren query bool false "为 true 时包含 columnId 的全部子孙栏目"
+// @Param        days      query  int     false  "统计最近多少天发布的文章，默认 30，传 0 表示不限"
+// @Param        limit     query  int     false  "返回条数，默认 10，最大 100"
+// @Param        fields    query  string  false  "只返回这些字段，逗号分隔；articleId 始终返回"
+// @Param        excludeFields query string false "不返回这些字段，逗号分隔"
+// @Param        contentFormat query string false "正文格式: html(默认)、text（纯文本）"
+// @Param        absoluteUrls  query bool   false "为 true 时将正文 src/href、封面图、附件中的站内地址补全为站点域名下的绝对地址"
+// @Param        excerpt       query int    false "summary 为空时从正文生成该长度（字符）的摘要，最大 1000"
+// @Success      200  {object}  util.Response
+// @Failure      400  {object}  util.Response
+// @Router       /api/v1/webplus/hot [get]
+// @Router       /api/v1/webplus/hot [post]
+func (h *Handler) GetHotArticles(c *gin.Context) {
+	days := 30
+	if s := util.GetParam(c, "days"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			util.Err(c, gin.H{"error": fmt.Sprintf("days 必须为非负整数: %s", s), "code": http.StatusBadRequest})
+			return
+		}
+		days = v
+	}
+	limit, _ := strconv.Atoi(util.GetParam(c, "limit"))
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	proj, err := h.parseFieldProjection(c)
+	if err != nil {
+		util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+		return
+	}
+
+	targetDB := db.GetTargetDB()
+	if targetDB == nil {
+		util.Err(c, fmt.Errorf("targetDB 未初始化"))
+		return
+	}
+
+	query := targetDB.Table(models.TableNameArticleStatic)
+
+	// 栏目 / 站点过滤，通过 article_dynamic 子查询限定文章范围
+	var filterColumnId string
+	if columnIdStr := util.GetParam(c, "columnId"); columnIdStr != "" {
+		columnIds, first := parseIDList(columnIdStr)
+		if len(columnIds) == 0 {
+			util.Ok(c, gin.H{"found": false, "items": []gin.H{}})
+			return
+		}
+		filterColumnId = first
+		if includeChildren, _ := strconv.ParseBool(util.GetParam(c, "includeChildren")); includeChildren {
+			expanded, err := expandColumnIds(targetDB, columnIds)
+			if err != nil {
+				util.Err(c, err)
+				return
+			}
+			columnIds = expanded
+		}
+		query = query.Where("articleId IN (?)", targetDB.Table(models.TableNameArticleDynamic).
+			Select("articleId").
+			Where("columnId IN ?", columnIds))
+	} else if siteIdStr := util.GetParam(c, "siteId"); siteIdStr != "" {
+		siteIds, _ := parseIDList(siteIdStr)
+		if len(siteIds) == 0 {
+			util.Ok(c, gin.H{"found": false, "items": []gin.H{}})
+			return
+		}
+		query = query.Where("articleId IN (?)", targetDB.Table(models.TableNameArticleDynamic).
+			Select("articleId").
+			Where("siteId IN ?", siteIds))
+	}
+
+	query = scopeArticleQuery(c, targetDB, query, "articleId")
+
+	if days > 0 {
+		query = query.Where("publishTime >= ?", time.Now().AddDate(0, 0, -days))
+	}
+
+	var rows []articleRow
+	if err := query.
+		Select(proj.selectColumns(articleSort{Column: "visitCount"})).
+		Order("visitCount DESC, publishTime DESC, articleId DESC").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		util.Err(c, fmt.Errorf("查询热门文章失败: %v", err))
+		return
+	}
+
+	articleIDs := make([]int64, 0, len(rows))
+	for _, r := range rows {
+		articleIDs = append(articleIDs, r.ArticleId)
+	}
+	columnMap, attachMap, err := loadArticleRelations(targetDB, articleIDs)
+	if err != nil {
+		util.Err(c, err)
+		return
+	}
+	scopeColumnMap(c, columnMap)
+
+	list := h.buildArticleItems(rows, columnMap, attachMap, filterColumnId, proj)
+	util.Ok(c, gin.H{
+		"found": len(list) > 0,
+		"items": list,
+	})
+}
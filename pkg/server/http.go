@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"os"
 	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/metrics"
+	"webplus-openapi/pkg/search"
 
 	_ "webplus-openapi/docs"
 
@@ -14,17 +16,28 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
 type Server struct {
-	srv  *http.Server
-	port int
+	srv      *http.Server
+	port     int
+	usage    *usageTracker
+	cache    *responseCache
+	webhooks *webhookDispatcher
+	// stopMetrics 关闭单独监听的指标服务
+	stopMetrics func()
 }
 
-func NewServer(cfg *Config) *Server {
+// NewServer 创建 http 服务，认证等配置有误时返回错误
+func NewServer(cfg *Config) (*Server, error) {
 	server := &Server{
-		port: cfg.Port,
+		port:     cfg.Port,
+		usage:    newUsageTracker(),
+		cache:    initResponseCache(cfg.Cache),
+		webhooks: initWebhookDispatcher(cfg.Webhook),
 	}
+	initEventBroker(cfg.Events)
 
 	// 根据环境变量设置Gin模式，默认为Release模式
 	ginMode := os.Getenv("GIN_MODE")
@@ -33,17 +46,42 @@ func NewServer(cfg *Config) *Server {
 	}
 	gin.SetMode(ginMode)
 	engine := gin.Default()
+	// 只信任配置的代理转发的 X-Forwarded-For，未配置时客户端 IP 取连接地址
+	if err := engine.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("trustedProxies 配置错误: %w", err)
+	}
+	if cfg.Metrics.IsEnabled() {
+		// 在注册路由前加入，所有路由都会记录
+		engine.Use(metricsMiddleware(cfg.Metrics.GetPath()))
+	}
 
 	// 创建handler实例（使用 db_storage 中的 MySQL 存储）
 	handler := &Handler{
-		cfg: *cfg,
-		db:  db.GetTargetDB(),
+		cfg:       *cfg,
+		db:        db.GetTargetDB(),
+		usage:     server.usage,
+		extFields: newExtFieldMapper(cfg.ExtFields),
+	}
+	handler.graphql = handler.newGraphQLSchema(cfg.GraphQL)
+	patchSwaggerDoc(handler.extFields, cfg.Search)
+
+	auth, err := authMiddleware(cfg.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("API Key 配置错误: %w", err)
 	}
 
+	ipRateLimit := ipRateLimitMiddleware(cfg.RateLimit)
 	zap.S().Info("开始注册路由...")
-	InitRouter(engine, handler)
+	InitRouter(engine, handler, ipRateLimit, auth, rateLimitMiddleware(cfg.RateLimit, server.usage))
 	zap.S().Info("路由注册完成")
 
+	// 指标配置了 listen 时单独监听，否则注册在服务端口上，只允许 admin API Key 访问
+	server.stopMetrics = metrics.Serve(cfg.Metrics)
+	if cfg.Metrics.IsEnabled() && cfg.Metrics.Listen == "" {
+		engine.GET(cfg.Metrics.GetPath(), ipRateLimit, auth, adminMiddleware(), gin.WrapH(metrics.Handler()))
+		zap.S().Infof("路由注册成功: GET %s", cfg.Metrics.GetPath())
+	}
+
 	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	server.srv = &http.Server{
@@ -51,7 +89,7 @@ func NewServer(cfg *Config) *Server {
 		Handler: engine,
 	}
 
-	return server
+	return server, nil
 }
 func (srv *Server) Run() error {
 	zap.S().Infof("HTTP服务器启动在端口 %d", srv.port)
@@ -66,9 +104,26 @@ func (srv *Server) Run() error {
 	return nil
 }
 
+// RunWorkers 运行 http 服务的后台任务（调用量写入、响应缓存清理、webhook 投递、全文检索索引同步等），ctx 结束时返回
+func (srv *Server) RunWorkers(ctx context.Context) error {
+	g, c := errgroup.WithContext(ctx)
+	g.Go(func() error { return srv.usage.run(c) })
+	if srv.cache != nil {
+		g.Go(func() error { return srv.cache.watchSyncState(c) })
+	}
+	if srv.webhooks != nil {
+		g.Go(func() error { return srv.webhooks.run(c) })
+	}
+	if idx := search.GetIndex(); idx != nil {
+		g.Go(func() error { return syncSearchIndex(c, idx) })
+	}
+	return g.Wait()
+}
+
 func (srv *Server) GracefulShutdown(ctx context.Context) error {
 	c, cancel := context.WithCancel(ctx)
 	defer cancel()
+	srv.stopMetrics()
 	if err := srv.srv.Shutdown(c); err != nil {
 		zap.S().Errorf("http server 关闭错误:%s", err.Error())
 		return err
@@ -11,14 +11,17 @@ import (
 	"sync"
 	"time"
 	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/metrics"
 	"webplus-openapi/pkg/models"
 	"webplus-openapi/pkg/nsc"
+	"webplus-openapi/pkg/search"
 
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
 )
 
 // Article 站群发的nats消息结构
@@ -96,6 +99,7 @@ func (w *Manager) Serve(cfg *Config, ctx context.Context) error {
 		return err
 	}
 	group, c := errgroup.WithContext(ctx)
+	group.Go(func() error { return watchNatsPending(c, consumer, consumerName) })
 	group.Go(func() error {
 		for {
 			select {
@@ -173,9 +177,13 @@ func (w *Manager) natsStreamMustReady() error {
 /**
  * 提取消息，持久化到文件流（可以理解为go的数据库）
  */
-func (w *Manager) handleOneMsg(msg jetstream.Msg) error {
+func (w *Manager) handleOneMsg(msg jetstream.Msg) (err error) {
+	start := time.Now()
 	var article Article
-	err := json.Unmarshal(msg.Data(), &article)
+	defer func() {
+		metrics.ObserveNatsMessage(operateLabel(article.Operate), err, time.Since(start))
+	}()
+	err = json.Unmarshal(msg.Data(), &article)
 	if err != nil {
 		zap.S().Error("JSON解析失败", zap.Error(err))
 		return err
@@ -229,6 +237,7 @@ func (w *Manager) QueryArticleById(result *Article) *models.ArticleInfo {
 		AuxiliaryTitle string `gorm:"column:auxiliaryTitle"`
 		CreatorName    string `gorm:"column:creatorName"`
 		Summary        string `gorm:"column:summary"`
+		LastModifyTime string `gorm:"column:lastModifyTime"`
 		PublishTime    string `gorm:"column:publishTime"`
 		PublisherName  string `gorm:"column:publisherName"`
 		PublishOrgName string `gorm:"column:publishOrgName"`
@@ -259,7 +268,7 @@ func (w *Manager) QueryArticleById(result *Article) *models.ArticleInfo {
 		"ta.linkUrl AS visitUrl, " +
 		"tc.id AS columnId, tc.name AS columnName, ta.title AS title, " +
 		"ta.shortTitle as shortTitle, ta.auxiliaryTitle as auxiliaryTitle, " +
-		"ta.creatorName as creatorName, ta.summary, " +
+		"ta.creatorName as creatorName, ta.summary, ta.lastModifyTime AS lastModifyTime, " +
 		"tsa.publishTime AS publishTime, tsa.publisherName AS publisherName, " +
 		"tsa.publishOrgName AS publishOrgName, ta.firstImgPath, " +
 		"ta.imagedir AS imageDir, ta.filepath AS filePath"
@@ -283,6 +292,7 @@ func (w *Manager) QueryArticleById(result *Article) *models.ArticleInfo {
 		AuxiliaryTitle: queryResult.AuxiliaryTitle,
 		CreatorName:    queryResult.CreatorName,
 		Summary:        queryResult.Summary,
+		LastModifyTime: ParsePgTime(queryResult.LastModifyTime),
 		PublishTime:    ParsePgTime(queryResult.PublishTime),
 		PublisherName:  queryResult.PublisherName,
 		PublishOrgName: queryResult.PublishOrgName,
@@ -402,8 +412,11 @@ func handleArticleUpsert(artInfo *models.ArticleInfo) error {
 	if artInfo == nil {
 		return fmt.Errorf("文章信息为空，无法存储")
 	}
-	if artInfo.LastModifyTime != nil && artInfo.LastModifyTime.IsZero() {
-		artInfo.LastModifyTime = nil
+	// 详情的 Last-Modified、sortBy=lastModifyTime 和 sitemap 的 lastmod 依赖修改时间，取源库 T_ARTICLE.lastModifyTime，
+	// 消息重投、重放时保持不变；源库没有记录时以处理消息的时间为准
+	if artInfo.LastModifyTime == nil {
+		now := time.Now()
+		artInfo.LastModifyTime = &now
 	}
 
 	targetDB := db.GetTargetDB()
@@ -416,11 +429,21 @@ func handleArticleUpsert(artInfo *models.ArticleInfo) error {
 		return fmt.Errorf("articleId 转换失败: %v", err)
 	}
 
+	// 文章原来所在的站点、栏目，变更后与新栏目一起淘汰缓存
+	cacheTags := articleCacheTags(targetDB, articleIDInt)
+
 	tx := targetDB.Begin()
 	if tx.Error != nil {
 		return fmt.Errorf("开启事务失败: %v", tx.Error)
 	}
 
+	// 文章此前是否存在，用于区分新增和修改
+	var existing int64
+	if err := tx.Table(models.TableNameArticleStatic).Where("articleId = ?", articleIDInt).Count(&existing).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("检查 article_static 失败: %v", err)
+	}
+
 	// 清理旧数据
 	if err := tx.Table(models.TableNameArticleStatic).Where("articleId = ?", articleIDInt).Delete(nil).Error; err != nil {
 		tx.Rollback()
@@ -468,7 +491,14 @@ func handleArticleUpsert(artInfo *models.ArticleInfo) error {
 		return fmt.Errorf("写入 article_static 失败: %v", err)
 	}
 
+	// 写入 article_field_index（扩展字段的精确匹配、范围过滤和排序）
+	if err := db.ReplaceArticleFieldIndex(tx, articleIDInt, artInfo.ArticleFields); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// 写入 article_dynamic（按当前 Id/Name 列表）
+	columnIds := make([]int64, 0, len(artInfo.ColumnId))
 	for i := range artInfo.ColumnId {
 		colIDStr := artInfo.ColumnId[i]
 		colName := ""
@@ -492,6 +522,7 @@ func handleArticleUpsert(artInfo *models.ArticleInfo) error {
 			tx.Rollback()
 			return fmt.Errorf("写入 article_dynamic 失败: %v", err)
 		}
+		columnIds = append(columnIds, colIDInt)
 	}
 
 	// 写入 article_attachment
@@ -509,14 +540,32 @@ func handleArticleUpsert(artInfo *models.ArticleInfo) error {
 		}
 	}
 
+	// 写入变更日志
+	change := &models.ArticleChangeLog{
+		ArticleId: articleIDInt,
+		Op:        models.ArticleChangeUpsert,
+		SiteId:    artInfo.SiteId,
+		ColumnIds: models.JoinColumnIds(columnIds),
+		Source:    models.ArticleChangeSourceNats,
+		Created:   existing == 0,
+	}
+	if err := recordArticleChange(tx, change); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return fmt.Errorf("提交事务失败: %v", err)
 	}
 
+	cacheTags = append(cacheTags, cacheTagSite(artInfo.SiteId))
+	evictResponseCache(append(cacheTags, columnCacheTags(targetDB, columnIds)...))
+	refreshSearchIndex(articleIDInt)
+	publishArticleChange(change)
 	return nil
 }
 
-// delArticleById 文章删除：删除 targetDB 中的 article_static / article_dynamic 记录
+// delArticleById 文章删除：删除 targetDB 中的 article_static / article_dynamic 及关联记录
 func delArticleById(msg *Article) error {
 	targetDB := db.GetTargetDB()
 	if targetDB == nil {
@@ -526,10 +575,17 @@ func delArticleById(msg *Article) error {
 	if err != nil {
 		return fmt.Errorf("articleId 转换失败: %v", err)
 	}
+	cacheTags := articleCacheTags(targetDB, articleIDInt)
 	tx := targetDB.Begin()
 	if tx.Error != nil {
 		return fmt.Errorf("开启事务失败: %v", tx.Error)
 	}
+	// 删除前查询墓碑，保留文章原来所在的站点和栏目
+	change, err := db.NewArticleDeleteChange(tx, articleIDInt, models.ArticleChangeSourceNats)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
 	if err := tx.Table(models.TableNameArticleStatic).Where("articleId = ?", articleIDInt).Delete(nil).Error; err != nil {
 		tx.Rollback()
 		return fmt.Errorf("删除 article_static 失败: %v", err)
@@ -542,9 +598,20 @@ func delArticleById(msg *Article) error {
 		tx.Rollback()
 		return fmt.Errorf("删除 article_attachment 失败: %v", err)
 	}
+	if err := tx.Table(models.TableNameArticleFieldIndex).Where("articleId = ?", articleIDInt).Delete(nil).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("删除 article_field_index 失败: %v", err)
+	}
+	if err := recordArticleChange(tx, change); err != nil {
+		tx.Rollback()
+		return err
+	}
 	if err := tx.Commit().Error; err != nil {
 		return fmt.Errorf("提交事务失败: %v", err)
 	}
+	evictResponseCache(cacheTags)
+	refreshSearchIndex(articleIDInt)
+	publishArticleChange(change)
 	return nil
 }
 
@@ -586,14 +653,44 @@ func updateColumnArtsByArtId(msg *Article) error {
 		"siteName":   querySiteInfo(msg.SiteId),
 		"url":        msg.VisitUrl,
 	}
-	if err := targetDB.Table(models.TableNameArticleDynamic).Create(&colRow).Error; err != nil {
-		return fmt.Errorf("写入 article_dynamic 失败: %v", err)
+	change := &models.ArticleChangeLog{
+		ArticleId: articleIDInt,
+		Op:        models.ArticleChangeColumnAdd,
+		SiteId:    msg.SiteId,
+		ColumnId:  colIDInt,
+		ColumnIds: models.JoinColumnIds([]int64{colIDInt}),
+		Source:    models.ArticleChangeSourceNats,
+	}
+	err = targetDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(models.TableNameArticleDynamic).Create(&colRow).Error; err != nil {
+			return fmt.Errorf("写入 article_dynamic 失败: %v", err)
+		}
+		if err := touchArticle(tx, articleIDInt); err != nil {
+			return err
+		}
+		return recordArticleChange(tx, change)
+	})
+	if err != nil {
+		return err
 	}
+	evictResponseCache(articleCacheTags(targetDB, articleIDInt))
+	refreshSearchIndex(articleIDInt)
+	publishArticleChange(change)
 
 	zap.S().Infof("成功为文章 %s 添加栏目ID: %s", msg.ArticleId, msg.PublishColumnId)
 	return nil
 }
 
+// touchArticle 文章所属栏目变化时更新修改时间，sortBy=lastModifyTime 和详情的 Last-Modified 随之变化
+func touchArticle(tx *gorm.DB, articleId int64) error {
+	if err := tx.Table(models.TableNameArticleStatic).
+		Where("articleId = ?", articleId).
+		Update("lastModifyTime", time.Now()).Error; err != nil {
+		return fmt.Errorf("更新 article_static 修改时间失败: %v", err)
+	}
+	return nil
+}
+
 // deleteColumnArtsByArtId 栏目文章删除：从 article_dynamic 中删除指定栏目记录
 func deleteColumnArtsByArtId(msg *Article) error {
 	targetDB := db.GetTargetDB()
@@ -610,16 +707,61 @@ func deleteColumnArtsByArtId(msg *Article) error {
 		return fmt.Errorf("columnId 转换失败: %v", err)
 	}
 
-	if err := targetDB.Table(models.TableNameArticleDynamic).
-		Where("articleId = ? AND columnId = ?", articleIDInt, colIDInt).
-		Delete(nil).Error; err != nil {
-		return fmt.Errorf("删除 article_dynamic 失败: %v", err)
+	cacheTags := articleCacheTags(targetDB, articleIDInt)
+	var change *models.ArticleChangeLog
+	err = targetDB.Transaction(func(tx *gorm.DB) error {
+		var siteIds []string
+		if err := tx.Table(models.TableNameArticleDynamic).
+			Where("articleId = ? AND columnId = ?", articleIDInt, colIDInt).
+			Pluck("siteId", &siteIds).Error; err != nil {
+			return fmt.Errorf("查询 article_dynamic 失败: %v", err)
+		}
+		if len(siteIds) == 0 {
+			// 文章不在该栏目中，没有变更
+			return nil
+		}
+		if err := tx.Table(models.TableNameArticleDynamic).
+			Where("articleId = ? AND columnId = ?", articleIDInt, colIDInt).
+			Delete(nil).Error; err != nil {
+			return fmt.Errorf("删除 article_dynamic 失败: %v", err)
+		}
+		if err := touchArticle(tx, articleIDInt); err != nil {
+			return err
+		}
+		change = &models.ArticleChangeLog{
+			ArticleId: articleIDInt,
+			Op:        models.ArticleChangeColumnRemove,
+			SiteId:    siteIds[0],
+			ColumnId:  colIDInt,
+			ColumnIds: models.JoinColumnIds([]int64{colIDInt}),
+			Source:    models.ArticleChangeSourceNats,
+		}
+		return recordArticleChange(tx, change)
+	})
+	if err != nil {
+		return err
+	}
+	evictResponseCache(cacheTags)
+	refreshSearchIndex(articleIDInt)
+	if change != nil {
+		publishArticleChange(change)
 	}
 
 	zap.S().Infof("成功从文章 %s 中移除栏目ID: %s", msg.ArticleId, msg.PublishColumnId)
 	return nil
 }
 
+// refreshSearchIndex 按 targetDB 中的最新数据更新文章的全文检索索引（文章已删除时从索引移除），失败只记录日志
+func refreshSearchIndex(articleId int64) {
+	idx := search.GetIndex()
+	if idx == nil {
+		return
+	}
+	if err := idx.IndexArticles(db.GetTargetDB(), []int64{articleId}); err != nil {
+		zap.S().Warnf("更新全文检索索引失败: articleId=%d, err=%v", articleId, err)
+	}
+}
+
 func getOpearteName(operate string) string {
 	operateName := ""
 	switch operate {
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"time"
+	"webplus-openapi/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+)
+
+// natsPendingInterval consumer 积压消息数的刷新间隔
+const natsPendingInterval = 15 * time.Second
+
+// metricsMiddleware 按路由记录请求数和耗时；route 取注册的路由模板，避免路径参数产生大量标签，
+// 未匹配到路由的请求记为 unmatched，指标接口本身不记录
+func metricsMiddleware(metricsPath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.URL.Path == metricsPath {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// operateLabel NATS 消息的 operate 作为指标标签，未知的值统一记为 unknown
+func operateLabel(operate string) string {
+	switch operate {
+	case OperateArtUpdate, OperateArtDelete, OperateColArtDelete, OperateColArtCreate, OperateArtVisit:
+		return operate
+	default:
+		return "unknown"
+	}
+}
+
+// watchNatsPending 定时查询 consumer 积压的消息数，listener 卡住时积压会持续增长；查询失败只记录日志
+func watchNatsPending(ctx context.Context, consumer jetstream.Consumer, consumerName string) error {
+	ticker := time.NewTicker(natsPendingInterval)
+	defer ticker.Stop()
+	for {
+		c, cancel := context.WithTimeout(ctx, 5*time.Second)
+		info, err := consumer.Info(c)
+		cancel()
+		if err != nil {
+			zap.S().Debugf("查询 consumer %s 状态失败: %v", consumerName, err)
+		} else {
+			metrics.SetNatsPending(consumerName, info.NumPending, info.NumAckPending)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
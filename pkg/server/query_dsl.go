@@ -0,0 +1,377 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"webplus-openapi/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// 过滤条件的嵌套层数和条件总数上限，避免生成过于复杂的 SQL
+const (
+	maxFilterDepth   = 5
+	maxFilterClauses = 100
+)
+
+// jsonValue JSON 中的字符串、数字或布尔值，统一按字符串处理
+type jsonValue string
+
+func (v *jsonValue) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	switch val := raw.(type) {
+	case string:
+		*v = jsonValue(val)
+	case json.Number:
+		*v = jsonValue(val.String())
+	case bool:
+		*v = jsonValue(strconv.FormatBool(val))
+	case nil:
+		*v = ""
+	default:
+		return fmt.Errorf("不支持的值: %s", string(b))
+	}
+	return nil
+}
+
+// jsonValues 值列表，既可以是 JSON 数组，也可以是逗号分隔的字符串
+type jsonValues []string
+
+func (vs *jsonValues) UnmarshalJSON(b []byte) error {
+	var list []jsonValue
+	if err := json.Unmarshal(b, &list); err != nil {
+		var single jsonValue
+		if err := json.Unmarshal(b, &single); err != nil {
+			return err
+		}
+		list = nil
+		for _, s := range strings.Split(string(single), ",") {
+			list = append(list, jsonValue(s))
+		}
+	}
+	*vs = (*vs)[:0]
+	for _, s := range list {
+		if t := strings.TrimSpace(string(s)); t != "" {
+			*vs = append(*vs, t)
+		}
+	}
+	return nil
+}
+
+// ArticleQuery POST getArticles 的 JSON 请求体（用于接口文档），未列出的 Query 参数同样可以放在请求体中
+type ArticleQuery struct {
+	ColumnIds       []string          `json:"columnIds,omitempty"` // 栏目ID，同 columnId
+	SiteIds         []string          `json:"siteIds,omitempty"`   // 站点ID，同 siteId，传 columnIds 时忽略
+	IncludeChildren bool              `json:"includeChildren,omitempty"`
+	Filter          *ArticleFilter    `json:"filter,omitempty"`
+	Sort            *ArticleQuerySort `json:"sort,omitempty"`
+	Page            int               `json:"page,omitempty"`
+	PageSize        int               `json:"pageSize,omitempty"`
+	Cursor          *string           `json:"cursor,omitempty"`
+	Fields          []string          `json:"fields,omitempty"`
+	ExcludeFields   []string          `json:"excludeFields,omitempty"`
+}
+
+// ArticleFilter POST getArticles 请求体中的 filter，可嵌套：
+// must 全部满足、should 至少满足一个、mustNot 都不满足（字段为空值的文章视为不满足）；同一节点中的多个条件按 AND 组合
+type ArticleFilter struct {
+	Must    []ArticleFilter `json:"must,omitempty"`
+	Should  []ArticleFilter `json:"should,omitempty"`
+	MustNot []ArticleFilter `json:"mustNot,omitempty"`
+
+	Site            jsonValues         `json:"site,omitempty" swaggertype:"array,string"`   // 所属站点ID
+	Column          jsonValues         `json:"column,omitempty" swaggertype:"array,string"` // 所属栏目ID
+	IncludeChildren bool               `json:"includeChildren,omitempty"`                   // column 是否包含子孙栏目
+	Field           *ArticleFieldMatch `json:"field,omitempty"`                             // 字段条件
+	Time            *ArticleTimeRange  `json:"time,omitempty"`                              // 时间范围
+}
+
+// ArticleFieldMatch 字段条件，name 可以是文章字段、fieldN 或扩展字段语义名称
+type ArticleFieldMatch struct {
+	Name string     `json:"name"`
+	Eq   jsonValue  `json:"eq,omitempty" swaggertype:"string"`       // 精确匹配
+	In   jsonValues `json:"in,omitempty" swaggertype:"array,string"` // 匹配任一值
+	From jsonValue  `json:"from,omitempty" swaggertype:"string"`     // 范围起点（含），int、date 类型扩展字段及 visitCount
+	To   jsonValue  `json:"to,omitempty" swaggertype:"string"`       // 范围终点（含）
+	Like string     `json:"like,omitempty"`                          // 模糊匹配
+}
+
+// ArticleTimeRange 时间范围，只传日期时 to 包含当天
+type ArticleTimeRange struct {
+	Field string `json:"field,omitempty"` // publishTime（默认）、lastModifyTime
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+}
+
+// ArticleQuerySort POST getArticles 请求体中的 sort，与 sortBy/order 参数等价
+type ArticleQuerySort struct {
+	By    string `json:"by"`
+	Order string `json:"order,omitempty"`
+}
+
+// articleFilterCompiler 将 ArticleFilter 编译为 article_static 上的 SQL 条件
+type articleFilterCompiler struct {
+	h        *Handler
+	targetDB *gorm.DB
+	clauses  int
+}
+
+// compileArticleFilter 编译过滤条件，返回可直接用于 Where 的条件和参数；条件为空时 cond 为空字符串
+func (h *Handler) compileArticleFilter(targetDB *gorm.DB, f ArticleFilter) (string, []interface{}, error) {
+	fc := &articleFilterCompiler{h: h, targetDB: targetDB}
+	return fc.compile(f, 1)
+}
+
+func (fc *articleFilterCompiler) compile(f ArticleFilter, depth int) (string, []interface{}, error) {
+	if depth > maxFilterDepth {
+		return "", nil, fmt.Errorf("filter 嵌套层数不能超过 %d", maxFilterDepth)
+	}
+	var (
+		parts []string
+		args  []interface{}
+	)
+	add := func(cond string, condArgs ...interface{}) error {
+		if cond == "" {
+			return nil
+		}
+		fc.clauses++
+		if fc.clauses > maxFilterClauses {
+			return fmt.Errorf("filter 条件数不能超过 %d", maxFilterClauses)
+		}
+		parts = append(parts, cond)
+		args = append(args, condArgs...)
+		return nil
+	}
+
+	if len(f.Site) > 0 {
+		ids, err := filterIDs("site", f.Site)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := add("articleId IN (?)", fc.dynamicSubquery("siteId IN ?", ids)); err != nil {
+			return "", nil, err
+		}
+	}
+	if len(f.Column) > 0 {
+		ids, err := filterIDs("column", f.Column)
+		if err != nil {
+			return "", nil, err
+		}
+		if f.IncludeChildren {
+			if ids, err = expandColumnIds(fc.targetDB, ids); err != nil {
+				return "", nil, err
+			}
+		}
+		if err := add("articleId IN (?)", fc.dynamicSubquery("columnId IN ?", ids)); err != nil {
+			return "", nil, err
+		}
+	}
+	if f.Field != nil {
+		cond, condArgs, err := fc.compileField(*f.Field)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := add(cond, condArgs...); err != nil {
+			return "", nil, err
+		}
+	}
+	if f.Time != nil {
+		cond, condArgs, err := compileTimeRange(*f.Time)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := add(cond, condArgs...); err != nil {
+			return "", nil, err
+		}
+	}
+
+	for _, sub := range f.Must {
+		cond, condArgs, err := fc.compile(sub, depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := add(cond, condArgs...); err != nil {
+			return "", nil, err
+		}
+	}
+	if len(f.Should) > 0 {
+		var (
+			ors     []string
+			orsArgs []interface{}
+		)
+		for _, sub := range f.Should {
+			cond, condArgs, err := fc.compile(sub, depth+1)
+			if err != nil {
+				return "", nil, err
+			}
+			// 空条件恒为真，整个 should 不再限制
+			if cond == "" {
+				ors = nil
+				break
+			}
+			ors = append(ors, cond)
+			orsArgs = append(orsArgs, condArgs...)
+		}
+		if len(ors) > 0 {
+			if err := add("("+strings.Join(ors, " OR ")+")", orsArgs...); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+	for _, sub := range f.MustNot {
+		cond, condArgs, err := fc.compile(sub, depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+		if cond == "" {
+			continue
+		}
+		// 用 IS NOT TRUE 而不是 NOT：字段为 NULL 时条件的结果为 NULL，NOT NULL 仍为 NULL 会把这些文章一起排除
+		if err := add(cond+" IS NOT TRUE", condArgs...); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if len(parts) == 0 {
+		return "", nil, nil
+	}
+	return "(" + strings.Join(parts, " AND ") + ")", args, nil
+}
+
+// dynamicSubquery article_dynamic 上满足条件的 articleId 子查询
+func (fc *articleFilterCompiler) dynamicSubquery(cond string, args ...interface{}) *gorm.DB {
+	return fc.targetDB.Table(models.TableNameArticleDynamic).Select("articleId").Where(cond, args...)
+}
+
+// compileField 编译字段条件：声明了类型的扩展字段走 article_field_index，其余字段直接比较 article_static 的列
+func (fc *articleFilterCompiler) compileField(m ArticleFieldMatch) (string, []interface{}, error) {
+	name := strings.ToLower(strings.TrimSpace(m.Name))
+	if name == "" {
+		return "", nil, fmt.Errorf("filter.field.name 不能为空")
+	}
+	ext := fc.h.extFields
+	var parts []string
+	var args []interface{}
+
+	column, ok := articleFieldColumns[name]
+	if !ok {
+		fields := ext.fieldsOf(name)
+		if len(fields) != 1 {
+			return "", nil, fmt.Errorf("filter.field.name 不支持的字段: %s", m.Name)
+		}
+		column = fields[0]
+	}
+	if column == "" {
+		return "", nil, fmt.Errorf("filter.field.name 不支持的字段: %s", m.Name)
+	}
+
+	if _, typed := ext.typeOf(column); typed {
+		param := "filter.field." + m.Name
+		sub, err := ext.extFieldSubquery(fc.targetDB, column, extFieldCond{
+			eq:   extParam{name: param + ".eq", value: string(m.Eq)},
+			in:   extParam{name: param + ".in", value: strings.Join(m.In, ",")},
+			from: extParam{name: param + ".from", value: string(m.From)},
+			to:   extParam{name: param + ".to", value: string(m.To)},
+		})
+		if err != nil {
+			return "", nil, err
+		}
+		if sub != nil {
+			parts = append(parts, "articleId IN (?)")
+			args = append(args, sub)
+		}
+	} else {
+		if m.Eq != "" {
+			parts = append(parts, column+" = ?")
+			args = append(args, string(m.Eq))
+		}
+		if len(m.In) > 0 {
+			parts = append(parts, column+" IN ?")
+			args = append(args, []string(m.In))
+		}
+		if m.From != "" || m.To != "" {
+			if column != "visitCount" {
+				return "", nil, fmt.Errorf("filter.field.%s: 只有 visitCount 和 int、date 类型的扩展字段支持范围过滤，时间请使用 filter.time", m.Name)
+			}
+			for i, v := range []jsonValue{m.From, m.To} {
+				if v == "" {
+					continue
+				}
+				n, err := strconv.ParseInt(string(v), 10, 64)
+				if err != nil {
+					return "", nil, fmt.Errorf("filter.field.%s 必须为整数: %s", m.Name, v)
+				}
+				if i == 0 {
+					parts = append(parts, column+" >= ?")
+				} else {
+					parts = append(parts, column+" <= ?")
+				}
+				args = append(args, n)
+			}
+		}
+	}
+	if m.Like != "" {
+		parts = append(parts, column+" LIKE ?")
+		args = append(args, "%"+strings.TrimSpace(m.Like)+"%")
+	}
+
+	if len(parts) == 0 {
+		return "", nil, nil
+	}
+	return "(" + strings.Join(parts, " AND ") + ")", args, nil
+}
+
+// compileTimeRange 编译发布时间或修改时间的范围条件
+func compileTimeRange(r ArticleTimeRange) (string, []interface{}, error) {
+	column := "publishTime"
+	switch strings.ToLower(r.Field) {
+	case "", "publishtime":
+	case "lastmodifytime":
+		column = "lastModifyTime"
+	default:
+		return "", nil, fmt.Errorf("filter.time.field 不支持: %s", r.Field)
+	}
+	var parts []string
+	var args []interface{}
+	if r.From != "" {
+		t, err := parseQueryTime(r.From, false)
+		if err != nil {
+			return "", nil, fmt.Errorf("filter.time.from 格式错误: %s", r.From)
+		}
+		parts = append(parts, column+" >= ?")
+		args = append(args, t)
+	}
+	if r.To != "" {
+		t, err := parseQueryTime(r.To, true)
+		if err != nil {
+			return "", nil, fmt.Errorf("filter.time.to 格式错误: %s", r.To)
+		}
+		parts = append(parts, column+" <= ?")
+		args = append(args, t)
+	}
+	if len(parts) == 0 {
+		return "", nil, nil
+	}
+	return "(" + strings.Join(parts, " AND ") + ")", args, nil
+}
+
+// filterIDs 将 site、column 中的ID转换为数字，包含非数字时返回错误
+func filterIDs(name string, values jsonValues) ([]int64, error) {
+	ids := make([]int64, 0, len(values))
+	for _, v := range values {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("filter.%s 必须为数字ID: %s", name, v)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
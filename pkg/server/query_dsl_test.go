@@ -0,0 +1,135 @@
+package server
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// titleEq 不依赖 targetDB 的字段条件
+func titleEq(v string) ArticleFilter {
+	return ArticleFilter{Field: &ArticleFieldMatch{Name: "title", Eq: jsonValue(v)}}
+}
+
+func TestCompileArticleFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   ArticleFilter
+		wantCond string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "empty",
+			filter:   ArticleFilter{},
+			wantCond: "",
+		},
+		{
+			name:     "must",
+			filter:   ArticleFilter{Must: []ArticleFilter{titleEq("a"), titleEq("b")}},
+			wantCond: "(((title = ?)) AND ((title = ?)))",
+			wantArgs: []interface{}{"a", "b"},
+		},
+		{
+			name:     "should",
+			filter:   ArticleFilter{Should: []ArticleFilter{titleEq("a"), titleEq("b")}},
+			wantCond: "((((title = ?)) OR ((title = ?))))",
+			wantArgs: []interface{}{"a", "b"},
+		},
+		{
+			name:     "empty should is always true",
+			filter:   ArticleFilter{Should: []ArticleFilter{titleEq("a"), {}}},
+			wantCond: "",
+		},
+		{
+			name: "empty should does not drop siblings",
+			filter: ArticleFilter{
+				Field:  &ArticleFieldMatch{Name: "keywords", Like: "x"},
+				Should: []ArticleFilter{{}, titleEq("a")},
+			},
+			wantCond: "((keywords LIKE ?))",
+			wantArgs: []interface{}{"%x%"},
+		},
+		{
+			name:     "mustNot keeps NULL columns",
+			filter:   ArticleFilter{MustNot: []ArticleFilter{titleEq("a")}},
+			wantCond: "(((title = ?)) IS NOT TRUE)",
+			wantArgs: []interface{}{"a"},
+		},
+		{
+			name:     "empty mustNot is ignored",
+			filter:   ArticleFilter{MustNot: []ArticleFilter{{}}},
+			wantCond: "",
+		},
+		{
+			name:     "visitCount range",
+			filter:   ArticleFilter{Field: &ArticleFieldMatch{Name: "visitCount", From: "10", To: "20"}},
+			wantCond: "((visitCount >= ? AND visitCount <= ?))",
+			wantArgs: []interface{}{int64(10), int64(20)},
+		},
+	}
+	h := &Handler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, args, err := h.compileArticleFilter(nil, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cond != tt.wantCond {
+				t.Errorf("cond = %s\nwant   %s", cond, tt.wantCond)
+			}
+			if len(args) != 0 || len(tt.wantArgs) != 0 {
+				if !reflect.DeepEqual(args, tt.wantArgs) {
+					t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileArticleFilterLimits(t *testing.T) {
+	nested := func(depth int) ArticleFilter {
+		f := titleEq("a")
+		for i := 1; i < depth; i++ {
+			f = ArticleFilter{Must: []ArticleFilter{f}}
+		}
+		return f
+	}
+	clauses := func(n int) ArticleFilter {
+		var f ArticleFilter
+		for i := 0; i < n; i++ {
+			f.Must = append(f.Must, titleEq("a"))
+		}
+		return f
+	}
+	tests := []struct {
+		name    string
+		filter  ArticleFilter
+		wantErr string
+	}{
+		{"max depth", nested(maxFilterDepth), ""},
+		{"too deep", nested(maxFilterDepth + 1), "嵌套层数不能超过"},
+		// 每个子条件本身和它在外层的 AND 各计一次
+		{"max clauses", clauses(maxFilterClauses / 2), ""},
+		{"too many clauses", clauses(maxFilterClauses/2 + 1), "条件数不能超过"},
+		{"unknown field", ArticleFilter{Field: &ArticleFieldMatch{Name: "nope", Eq: "1"}}, "不支持的字段"},
+		{"range on text field", ArticleFilter{Field: &ArticleFieldMatch{Name: "title", From: "1"}}, "支持范围过滤"},
+		{"non numeric range", ArticleFilter{Field: &ArticleFieldMatch{Name: "visitCount", From: "x"}}, "必须为整数"},
+		{"bad site id", ArticleFilter{Site: jsonValues{"x"}}, "必须为数字ID"},
+		{"bad time", ArticleFilter{Time: &ArticleTimeRange{From: "yesterday"}}, "filter.time.from"},
+	}
+	h := &Handler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := h.compileArticleFilter(nil, tt.filter)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitConfig 限流与配额配置
+type RateLimitConfig struct {
+	// Enabled 是否启用限流
+	Enabled bool `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	// KeyRate 每个 API Key 每秒允许的请求数，0 表示不限制；可在 auth.keys 中单独覆盖
+	KeyRate float64 `json:"key_rate,omitempty" yaml:"keyRate,omitempty" mapstructure:"keyRate"`
+	// KeyBurst 每个 API Key 允许的突发请求数，默认与 KeyRate 相同
+	KeyBurst int `json:"key_burst,omitempty" yaml:"keyBurst,omitempty" mapstructure:"keyBurst"`
+	// IPRate 每个 IP 每秒允许的请求数，0 表示不限制
+	IPRate float64 `json:"ip_rate,omitempty" yaml:"ipRate,omitempty" mapstructure:"ipRate"`
+	// IPBurst 每个 IP 允许的突发请求数，默认与 IPRate 相同
+	IPBurst int `json:"ip_burst,omitempty" yaml:"ipBurst,omitempty" mapstructure:"ipBurst"`
+	// DailyQuota 每个 API Key 每日请求数上限，0 表示不限制；可在 auth.keys 中单独覆盖
+	DailyQuota int64 `json:"daily_quota,omitempty" yaml:"dailyQuota,omitempty" mapstructure:"dailyQuota"`
+}
+
+// tokenBucket 令牌桶，按 rate 匀速补充令牌，最多积累 burst 个
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// take 取一个令牌，取不到时返回需要等待的时间
+func (b *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// bucketSet 按标识（API Key 名称或 IP）管理令牌桶
+type bucketSet struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func newBucketSet() *bucketSet {
+	return &bucketSet{buckets: make(map[string]*tokenBucket), lastSweep: time.Now()}
+}
+
+// take 从 id 对应的令牌桶中取一个令牌，rate <= 0 时不限制
+func (s *bucketSet) take(id string, rate float64, burst int, now time.Time) (bool, time.Duration) {
+	if rate <= 0 {
+		return true, 0
+	}
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 定期清理已经补满的桶，避免大量 IP 占用内存
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, b := range s.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b, ok := s.buckets[id]
+	if !ok || b.rate != rate || b.burst != float64(burst) {
+		b = &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: now}
+		s.buckets[id] = b
+	}
+	return b.take(now)
+}
+
+// ipRateLimitMiddleware 按 IP 限流，需放在 authMiddleware 之前，使用无效 Key 的请求同样受限；
+// IP 取自 c.ClientIP()，只有来自 trustedProxies 的 X-Forwarded-For 才会被采用
+func ipRateLimitMiddleware(cfg *RateLimitConfig) gin.HandlerFunc {
+	if cfg == nil || !cfg.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+	ipBuckets := newBucketSet()
+
+	return func(c *gin.Context) {
+		if ok, wait := ipBuckets.take(c.ClientIP(), cfg.IPRate, cfg.IPBurst, time.Now()); !ok {
+			tooManyRequests(c, wait, "请求过于频繁，请稍后再试")
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitMiddleware 按 API Key 限流，并统计、校验每日配额，需放在 authMiddleware 之后
+func rateLimitMiddleware(cfg *RateLimitConfig, usage *usageTracker) gin.HandlerFunc {
+	if cfg == nil || !cfg.Enabled {
+		// 未启用限流时仍然统计调用量
+		return func(c *gin.Context) {
+			usage.add(clientName(c), time.Now())
+			c.Next()
+		}
+	}
+	keyBuckets := newBucketSet()
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		name := clientName(c)
+		if cl := currentClient(c); cl != nil {
+			rate, burst := cfg.KeyRate, cfg.KeyBurst
+			if cl.Rate > 0 {
+				rate, burst = cl.Rate, cl.Burst
+			}
+			// 令牌桶按 Key 区分，每个 Key 单独计算
+			if ok, wait := keyBuckets.take(cl.id, rate, burst, now); !ok {
+				tooManyRequests(c, wait, "请求过于频繁，请稍后再试")
+				return
+			}
+
+			quota := cfg.DailyQuota
+			if cl.DailyQuota > 0 {
+				quota = cl.DailyQuota
+			}
+			if quota > 0 {
+				used, err := usage.count(name, now)
+				if err != nil {
+					// 无法确认配额时拒绝请求，下次请求重新加载
+					util.Err(c, gin.H{"error": "暂时无法校验每日请求配额，请稍后再试", "code": http.StatusServiceUnavailable})
+					return
+				}
+				if used >= quota {
+					tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+					tooManyRequests(c, tomorrow.Sub(now), fmt.Sprintf("已超出每日请求配额 %d", quota))
+					return
+				}
+			}
+		}
+
+		usage.add(name, now)
+		c.Next()
+	}
+}
+
+// tooManyRequests 返回 429，并通过 Retry-After 告知调用方需要等待的秒数
+func tooManyRequests(c *gin.Context, wait time.Duration, message string) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	util.Err(c, gin.H{"error": message, "code": http.StatusTooManyRequests})
+}
+
+// clientName 当前调用方名称，未启用认证时为 anonymous
+func clientName(c *gin.Context) string {
+	if cl := currentClient(c); cl != nil {
+		return cl.Name
+	}
+	return anonymousClient
+}
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"webplus-openapi/pkg/util"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -8,31 +10,118 @@ import (
 // APIHandler 定义API处理器接口
 type APIHandler interface {
 	GetArticles(c *gin.Context)
+	GetArticle(c *gin.Context)
+	GetArticlesByIds(c *gin.Context)
 	GetColumns(c *gin.Context)
 	GetSites(c *gin.Context)
+	Search(c *gin.Context)
+	GetStats(c *gin.Context)
+	GetHotArticles(c *gin.Context)
+	GetColumnFeed(c *gin.Context)
+	GetSiteFeed(c *gin.Context)
+	GetSitemap(c *gin.Context)
+	GetUsage(c *gin.Context)
+	GetFieldDefinitions(c *gin.Context)
+	GetChanges(c *gin.Context)
+	GetEvents(c *gin.Context)
+	GraphQL(c *gin.Context)
+	ListWebhooks(c *gin.Context)
+	CreateWebhook(c *gin.Context)
+	UpdateWebhook(c *gin.Context)
+	DeleteWebhook(c *gin.Context)
+	GetWebhookDeliveries(c *gin.Context)
+	ReplayWebhook(c *gin.Context)
+	ReplayWebhookDelivery(c *gin.Context)
 }
 
-// InitRouter 初始化路由配置
-func InitRouter(engine *gin.Engine, handler APIHandler) *gin.RouterGroup {
+// InitRouter 初始化路由配置，middlewares 作用于 /api/v1/webplus 下的全部接口
+func InitRouter(engine *gin.Engine, handler APIHandler, middlewares ...gin.HandlerFunc) *gin.RouterGroup {
 	// API路由组
 	apiGroup := engine.Group("/api/v1")
 	if handler != nil {
-		webplus := apiGroup.Group("/webplus")
+		// JSON 请求体在业务处理前解析，格式错误直接返回 400
+		webplus := apiGroup.Group("/webplus", middlewares...)
+		webplus.Use(util.JSONBody())
 		{
 			// getArticles 支持 GET 和 POST
-			webplus.GET("/getArticles", handler.GetArticles)
-			webplus.POST("/getArticles", handler.GetArticles)
+			webplus.GET("/getArticles", cached(articleListCacheTags, handler.GetArticles))
+			webplus.POST("/getArticles", cached(articleListCacheTags, handler.GetArticles))
 			zap.S().Info("路由注册成功: GET/POST /api/v1/webplus/getArticles")
 
+			// getArticle 文章详情
+			webplus.GET("/getArticle/:articleId", handler.GetArticle)
+			zap.S().Info("路由注册成功: GET /api/v1/webplus/getArticle/:articleId")
+
+			// getArticlesByIds 按ID批量获取文章，支持 GET 和 POST
+			webplus.GET("/getArticlesByIds", cached(allCacheTags, handler.GetArticlesByIds))
+			webplus.POST("/getArticlesByIds", cached(allCacheTags, handler.GetArticlesByIds))
+			zap.S().Info("路由注册成功: GET/POST /api/v1/webplus/getArticlesByIds")
+
 			// getColumns 支持 GET 和 POST
-			webplus.GET("/getColumns", handler.GetColumns)
-			webplus.POST("/getColumns", handler.GetColumns)
+			webplus.GET("/getColumns", cached(noCacheTags, handler.GetColumns))
+			webplus.POST("/getColumns", cached(noCacheTags, handler.GetColumns))
 			zap.S().Info("路由注册成功: GET/POST /api/v1/webplus/getColumns")
 
 			// getSites 支持 GET 和 POST
-			webplus.GET("/getSites", handler.GetSites)
-			webplus.POST("/getSites", handler.GetSites)
+			webplus.GET("/getSites", cached(noCacheTags, handler.GetSites))
+			webplus.POST("/getSites", cached(noCacheTags, handler.GetSites))
 			zap.S().Info("路由注册成功: GET/POST /api/v1/webplus/getSites")
+
+			// search 全文检索
+			webplus.GET("/search", handler.Search)
+			webplus.POST("/search", handler.Search)
+			zap.S().Info("路由注册成功: GET/POST /api/v1/webplus/search")
+
+			// stats 文章数量统计
+			webplus.GET("/stats", handler.GetStats)
+			webplus.POST("/stats", handler.GetStats)
+			zap.S().Info("路由注册成功: GET/POST /api/v1/webplus/stats")
+
+			// hot 热门文章
+			webplus.GET("/hot", handler.GetHotArticles)
+			webplus.POST("/hot", handler.GetHotArticles)
+			zap.S().Info("路由注册成功: GET/POST /api/v1/webplus/hot")
+
+			// feeds RSS/Atom 订阅源，如 /feeds/column/123.xml、/feeds/site/12.atom
+			webplus.GET("/feeds/column/:columnId", handler.GetColumnFeed)
+			webplus.GET("/feeds/site/:siteId", handler.GetSiteFeed)
+			zap.S().Info("路由注册成功: GET /api/v1/webplus/feeds/column/:columnId, /api/v1/webplus/feeds/site/:siteId")
+
+			// sitemap 站点 sitemap，如 /sitemap/12.xml、/sitemap/12.xml?page=2
+			webplus.GET("/sitemap/:siteId", handler.GetSitemap)
+			zap.S().Info("路由注册成功: GET /api/v1/webplus/sitemap/:siteId")
+
+			// fieldDefinitions 扩展字段语义名称
+			webplus.GET("/fieldDefinitions", handler.GetFieldDefinitions)
+			zap.S().Info("路由注册成功: GET /api/v1/webplus/fieldDefinitions")
+
+			// usage 调用量统计
+			webplus.GET("/usage", handler.GetUsage)
+			zap.S().Info("路由注册成功: GET /api/v1/webplus/usage")
+
+			// changes 增量变更，包括删除的墓碑
+			webplus.GET("/changes", handler.GetChanges)
+			zap.S().Info("路由注册成功: GET /api/v1/webplus/changes")
+
+			// events 文章变更实时推送（SSE）
+			webplus.GET("/events", handler.GetEvents)
+			zap.S().Info("路由注册成功: GET /api/v1/webplus/events")
+
+			// graphql 站点、栏目、文章的 GraphQL 查询
+			webplus.GET("/graphql", handler.GraphQL)
+			webplus.POST("/graphql", handler.GraphQL)
+			zap.S().Info("路由注册成功: GET/POST /api/v1/webplus/graphql")
+
+			// admin 管理接口，需要 admin API Key
+			admin := webplus.Group("/admin", adminMiddleware())
+			admin.GET("/webhooks", handler.ListWebhooks)
+			admin.POST("/webhooks", handler.CreateWebhook)
+			admin.PUT("/webhooks/:id", handler.UpdateWebhook)
+			admin.DELETE("/webhooks/:id", handler.DeleteWebhook)
+			admin.GET("/webhooks/:id/deliveries", handler.GetWebhookDeliveries)
+			admin.POST("/webhooks/:id/replay", handler.ReplayWebhook)
+			admin.POST("/webhookDeliveries/:id/replay", handler.ReplayWebhookDelivery)
+			zap.S().Info("路由注册成功: /api/v1/webplus/admin/webhooks")
 		}
 	} else {
 		zap.S().Warn("Handler为nil，路由未注册")
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/search"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+)
+
+// Search 全文检索文章
+// @Summary      全文检索文章
+// @Description  基于内嵌全文索引检索标题、摘要和正文，按相关度排序并返回高亮片段
+// @Tags         articles
+// @Produce      json
+// @Param        q         query  string  true   "检索关键字"
+// @Param        siteId    query  string  false  "站点ID，逗号分隔"
+// @Param        columnId  query  string  false  "栏目ID，逗号分隔"
+// @Param        page      query  int     false  "页码，从1开始"
+// @Param        pageSize  query  int     false  "每页大小"
+// @Param        fields    query  string  false  "只返回这些字段，逗号分隔；articleId 始终返回"
+// @Param        excludeFields query string false "不返回这些字段，逗号分隔"
+// @Param        contentFormat query string false "正文格式: html(默认)、text（纯文本）"
+// @Param        absoluteUrls  query bool   false "为 true 时将正文 src/href、封面图、附件中的站内地址补全为站点域名下的绝对地址"
+// @Param        excerpt       query int    false "summary 为空时从正文生成该长度（字符）的摘要，最大 1000"
+// @Success      200  {object}  util.Response
+// @Router       /api/v1/webplus/search [get]
+// @Router       /api/v1/webplus/search [post]
+func (h *Handler) Search(c *gin.Context) {
+	idx := search.GetIndex()
+	if idx == nil {
+		util.Err(c, gin.H{"error": "全文检索未启用", "code": http.StatusNotImplemented})
+		return
+	}
+	q := strings.TrimSpace(util.GetParam(c, "q"))
+	if q == "" {
+		util.Err(c, gin.H{"error": "q 不能为空", "code": http.StatusBadRequest})
+		return
+	}
+	page, pageSize := parsePaging(c)
+	proj, err := h.parseFieldProjection(c)
+	if err != nil {
+		util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+		return
+	}
+
+	req := search.Request{
+		Query: q,
+		From:  (page - 1) * pageSize,
+		Size:  pageSize,
+	}
+	columnIds, filterColumnId := parseIDList(util.GetParam(c, "columnId"))
+	for _, id := range columnIds {
+		req.ColumnIds = append(req.ColumnIds, strconv.FormatInt(id, 10))
+	}
+	siteIds, _ := parseIDList(util.GetParam(c, "siteId"))
+	for _, id := range siteIds {
+		req.SiteIds = append(req.SiteIds, strconv.FormatInt(id, 10))
+	}
+
+	if cl := currentClient(c); cl.restricted() {
+		req.ScopeSiteIds = cl.SiteIds
+		for _, id := range cl.ColumnIds {
+			req.ScopeColumnIds = append(req.ScopeColumnIds, strconv.FormatInt(id, 10))
+		}
+	}
+
+	res, err := idx.Search(req)
+	if err != nil {
+		util.Err(c, err)
+		return
+	}
+
+	targetDB := db.GetTargetDB()
+	if targetDB == nil {
+		util.Err(c, fmt.Errorf("targetDB 未初始化"))
+		return
+	}
+
+	// 按检索结果的顺序读取文章，索引中存在但库中已删除的文章直接跳过
+	articleIDs := make([]int64, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		articleIDs = append(articleIDs, hit.ArticleId)
+	}
+	var rows []articleRow
+	if len(articleIDs) > 0 {
+		if err := scopeArticleQuery(c, targetDB, targetDB.Table(models.TableNameArticleStatic), "articleId").
+			Select(proj.selectColumns(articleSort{})).
+			Where("articleId IN ?", articleIDs).
+			Scan(&rows).Error; err != nil {
+			util.Err(c, fmt.Errorf("查询文章列表失败: %v", err))
+			return
+		}
+	}
+	rowMap := make(map[int64]articleRow, len(rows))
+	for _, r := range rows {
+		rowMap[r.ArticleId] = r
+	}
+	ordered := make([]articleRow, 0, len(rows))
+	hits := make([]search.Hit, 0, len(rows))
+	for _, hit := range res.Hits {
+		if r, ok := rowMap[hit.ArticleId]; ok {
+			ordered = append(ordered, r)
+			hits = append(hits, hit)
+		}
+	}
+
+	columnMap, attachMap, err := loadArticleRelations(targetDB, articleIDs)
+	if err != nil {
+		util.Err(c, err)
+		return
+	}
+	scopeColumnMap(c, columnMap)
+	list := h.buildArticleItems(ordered, columnMap, attachMap, filterColumnId, proj)
+	for i, item := range list {
+		item["score"] = hits[i].Score
+		item["highlights"] = hits[i].Highlights
+	}
+
+	util.Ok(c, gin.H{
+		"found": len(list) > 0,
+		"items": list,
+		"pagination": gin.H{
+			"page":     page,
+			"pageSize": pageSize,
+			"hasNext":  uint64(page*pageSize) < res.Total,
+			"total":    res.Total,
+		},
+	})
+}
+
+// 同步数据恢复变更到全文检索索引的间隔和每批读取的变更日志条数
+const (
+	searchIndexSyncInterval  = 10 * time.Second
+	searchIndexSyncBatchSize = 500
+)
+
+// syncSearchIndex 数据恢复不写全文检索索引（索引目录只能被一个进程打开），由 api 服务按 seq 读取 source=recover 的变更日志更新索引；
+// 已同步到的 seq 保存在索引中，重启后继续，失败只记录日志，下一轮重试
+func syncSearchIndex(ctx context.Context, idx *search.Index) error {
+	ticker := time.NewTicker(searchIndexSyncInterval)
+	defer ticker.Stop()
+	for {
+		if err := indexRecoverChanges(idx); err != nil {
+			zap.S().Warnf("同步数据恢复的全文检索索引失败: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// indexRecoverChanges 处理同步位置之后、当前最大 seq 之前的恢复变更；seq 按提交顺序分配，最大 seq 之前的变更都已提交，
+// 没有恢复变更时也把同步位置推进到最大 seq，避免每轮重复扫描 NATS 产生的变更日志
+func indexRecoverChanges(idx *search.Index) error {
+	targetDB := db.GetTargetDB()
+	if targetDB == nil {
+		return nil
+	}
+	since, err := idx.ChangeLogSeq()
+	if err != nil {
+		return err
+	}
+	var maxSeq int64
+	if err := targetDB.Model(&models.ArticleChangeLog{}).Select("COALESCE(MAX(seq), 0)").Scan(&maxSeq).Error; err != nil {
+		return fmt.Errorf("查询变更日志最大 seq 失败: %v", err)
+	}
+	for since < maxSeq {
+		var logs []models.ArticleChangeLog
+		if err := targetDB.Model(&models.ArticleChangeLog{}).
+			Select("seq, articleId").
+			Where("seq > ? AND seq <= ? AND source = ?", since, maxSeq, models.ArticleChangeSourceRecover).
+			Order("seq ASC").
+			Limit(searchIndexSyncBatchSize).
+			Find(&logs).Error; err != nil {
+			return fmt.Errorf("查询恢复变更失败: %v", err)
+		}
+		next := maxSeq
+		if len(logs) == searchIndexSyncBatchSize {
+			next = logs[len(logs)-1].Seq
+		}
+		articleIds := lo.Uniq(lo.Map(logs, func(l models.ArticleChangeLog, _ int) int64 { return l.ArticleId }))
+		if err := idx.IndexArticles(targetDB, articleIds); err != nil {
+			return err
+		}
+		if err := idx.SetChangeLogSeq(next); err != nil {
+			return err
+		}
+		if len(articleIds) > 0 {
+			zap.S().Infof("已将 %d 篇恢复的文章写入全文检索索引，同步到 seq=%d", len(articleIds), next)
+		}
+		since = next
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"webplus-openapi/pkg/models"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+// domainSeparatorRe T_SITE.DOMAINNAME 中多个域名的分隔符
+var domainSeparatorRe = regexp.MustCompile(`[,，]+`)
+
+// firstDomain 取 DOMAINNAME 中的第一个域名
+func firstDomain(domainName string) string {
+	for _, part := range domainSeparatorRe.Split(domainName, -1) {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
+
+// loadSiteDomains 批量计算站点域名，规则与 getSiteDomainName 相同：
+// 站点有自己的 domainName 时取第一个，否则通过 T_PUBLISHSITE 的 parentId 找到父站点域名并拼接自己的 DummyName
+func loadSiteDomains(targetDB *gorm.DB, sites []models.TSite) map[int]string {
+	domains := make(map[int]string, len(sites))
+	var pending []models.TSite
+	for _, s := range sites {
+		if domain := firstDomain(s.DomainName); domain != "" {
+			domains[s.Id] = domain
+		} else {
+			pending = append(pending, s)
+		}
+	}
+	if len(pending) == 0 {
+		return domains
+	}
+
+	// 站点的发布记录 -> 父发布记录 -> 父站点
+	var publishSites []models.TPublishSite
+	if err := targetDB.Table(models.TableNameTPubSite).
+		Where("siteId IN ? AND deleted = ?", lo.Map(pending, func(s models.TSite, _ int) int { return s.Id }), 0).
+		Find(&publishSites).Error; err != nil {
+		return domains
+	}
+	siteToPublish := lo.KeyBy(publishSites, func(ps models.TPublishSite) int { return ps.SiteId })
+	parentIds := lo.Uniq(lo.FilterMap(publishSites, func(ps models.TPublishSite, _ int) (int, bool) { return ps.ParentId, ps.ParentId > 0 }))
+	if len(parentIds) == 0 {
+		return domains
+	}
+	var parentPublishSites []models.TPublishSite
+	if err := targetDB.Table(models.TableNameTPubSite).
+		Where("id IN ? AND deleted = ?", parentIds, 0).
+		Find(&parentPublishSites).Error; err != nil {
+		return domains
+	}
+	parentPublish := lo.KeyBy(parentPublishSites, func(ps models.TPublishSite) int { return ps.Id })
+	parentSiteIds := lo.Uniq(lo.FilterMap(parentPublishSites, func(ps models.TPublishSite, _ int) (int, bool) { return ps.SiteId, ps.SiteId > 0 }))
+	if len(parentSiteIds) == 0 {
+		return domains
+	}
+	var parentSites []models.TSite
+	if err := targetDB.Table(models.TableNameTSite).Where("ID IN ?", parentSiteIds).Find(&parentSites).Error; err != nil {
+		return domains
+	}
+	parentSiteMap := lo.KeyBy(parentSites, func(s models.TSite) int { return s.Id })
+
+	for _, s := range pending {
+		ps, ok := siteToPublish[s.Id]
+		if !ok || ps.ParentId <= 0 {
+			continue
+		}
+		pps, ok := parentPublish[ps.ParentId]
+		if !ok {
+			continue
+		}
+		parentDomain := firstDomain(parentSiteMap[pps.SiteId].DomainName)
+		switch {
+		case parentDomain == "":
+		case s.DummyName != "":
+			domains[s.Id] = parentDomain + "/" + s.DummyName
+		default:
+			domains[s.Id] = parentDomain
+		}
+	}
+	return domains
+}
+
+// loadSiteDomainsById 按站点ID批量计算站点域名
+func loadSiteDomainsById(targetDB *gorm.DB, siteIds []int) map[int]string {
+	if len(siteIds) == 0 {
+		return map[int]string{}
+	}
+	var sites []models.TSite
+	if err := targetDB.Table(models.TableNameTSite).Where("ID IN ?", lo.Uniq(siteIds)).Find(&sites).Error; err != nil {
+		return map[int]string{}
+	}
+	return loadSiteDomains(targetDB, sites)
+}
+
+// buildSiteInfos 将 T_SITE 记录转换为响应格式：T_PUBLISHSITE 中存在且 deleted = 0 的站点为已发布，已发布的站点才返回地址和 logo
+func buildSiteInfos(targetDB *gorm.DB, sites []models.TSite) []SiteInfo {
+	published := make(map[int]bool)
+	if len(sites) > 0 {
+		var publishedIds []int
+		if err := targetDB.Table(models.TableNameTPubSite).
+			Where("siteId IN ? AND deleted = ?", lo.Map(sites, func(s models.TSite, _ int) int { return s.Id }), 0).
+			Pluck("siteId", &publishedIds).Error; err == nil {
+			for _, id := range publishedIds {
+				published[id] = true
+			}
+		}
+	}
+	domains := loadSiteDomains(targetDB, lo.Filter(sites, func(s models.TSite, _ int) bool { return published[s.Id] }))
+
+	list := make([]SiteInfo, len(sites))
+	for i, s := range sites {
+		status := 0
+		siteUrl := ""
+		if published[s.Id] {
+			status = 1
+			siteUrl = domains[s.Id]
+		}
+		logoURL := ""
+		if s.Logo != "" && siteUrl != "" {
+			logoURL = "http://" + siteUrl + path.Join("/_upload", s.FilePath, s.Logo)
+		}
+		list[i] = SiteInfo{
+			SiteId:    s.Id,
+			SiteName:  s.Name,
+			Status:    status,
+			SiteUrl:   siteUrl,
+			ShortName: s.ShortName,
+			Logo:      logoURL,
+		}
+	}
+	return list
+}
+
+// buildColumnInfos 将 T_COLUMN 记录转换为响应格式，站点域名和路径中的栏目名称都批量查询
+func (h *Handler) buildColumnInfos(targetDB *gorm.DB, columns []models.TColumn) []ColumnInfo {
+	// 没有 link 的栏目按站点域名生成地址
+	domains := loadSiteDomainsById(targetDB, lo.FilterMap(columns, func(col models.TColumn, _ int) (int, bool) { return col.SiteId, col.Link == "" }))
+
+	// 路径中所有栏目的名称
+	pathIds := make([][]int, len(columns))
+	var allIds []int
+	for i, col := range columns {
+		pathIds[i] = h.extractIdsFromPath(col.Path)
+		allIds = append(allIds, pathIds[i]...)
+	}
+	columnIdToName := make(map[int]string)
+	if len(allIds) > 0 {
+		var pathColumns []models.TColumn
+		if err := targetDB.Table(models.TableNameTColumn).
+			Where("id IN ?", lo.Uniq(allIds)).
+			Select("id, name").
+			Find(&pathColumns).Error; err == nil {
+			for _, pathCol := range pathColumns {
+				columnIdToName[pathCol.Id] = pathCol.Name
+			}
+		}
+	}
+
+	list := make([]ColumnInfo, len(columns))
+	for i := range columns {
+		col := &columns[i]
+		link := col.Link
+		if link == "" {
+			link = buildColumnUrl(domains[col.SiteId], col.UrlName)
+		}
+		list[i] = ColumnInfo{
+			ColumnId:       col.Id,
+			ColumnName:     col.Name,
+			ParentColumnId: col.ParentId,
+			ColumnUrl:      link,
+			Path:           h.convertPathToChineseWithCache(col.Path, columnIdToName, col, pathIds[i]),
+			Sort:           col.Sort,
+			Status:         col.Navigation, //暂时按哈工大的需求来。如果是导航栏目就是显示
+		}
+	}
+	return list
+}
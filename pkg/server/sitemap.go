@@ -0,0 +1,209 @@
+package server
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// sitemapMaxUrls 单个 sitemap 文件允许的最大 URL 数（sitemaps.org 协议限制）
+const sitemapMaxUrls = 50000
+
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type sitemapUrlSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Urls    []sitemapUrl `xml:"url"`
+}
+
+type sitemapUrl struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name         `xml:"sitemapindex"`
+	Xmlns    string           `xml:"xmlns,attr"`
+	Sitemaps []sitemapPointer `xml:"sitemap"`
+}
+
+type sitemapPointer struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// sitemapArticleRow 站点下的文章地址
+type sitemapArticleRow struct {
+	Url            string     `gorm:"column:url"`
+	LastModifyTime *time.Time `gorm:"column:lastModifyTime"`
+}
+
+// GetSitemap 站点 sitemap
+// @Summary      站点 sitemap
+// @Description  输出站点全部栏目列表页和文章地址，超过 50000 条时返回 sitemap index，分页通过 page 参数获取
+// @Tags         feeds
+// @Produce      xml
+// @Param        siteId  path   string  true   "站点ID，如 12.xml"
+// @Param        page    query  int     false  "分页序号，从 1 开始；URL 数超过 50000 时不传返回 sitemap index"
+// @Success      200  {string}  string
+// @Failure      404  {object}  util.Response
+// @Router       /api/v1/webplus/sitemap/{siteId} [get]
+func (h *Handler) GetSitemap(c *gin.Context) {
+	idStr := strings.TrimSuffix(strings.TrimSpace(c.Param("siteId")), ".xml")
+	siteId, err := strconv.Atoi(idStr)
+	if err != nil {
+		util.Err(c, gin.H{"error": fmt.Sprintf("siteId 必须为数字: %s", idStr), "code": http.StatusBadRequest})
+		return
+	}
+	page := 0
+	if s := util.GetParam(c, "page"); s != "" {
+		if page, err = strconv.Atoi(s); err != nil || page < 1 {
+			util.Err(c, gin.H{"error": fmt.Sprintf("page 必须为正整数: %s", s), "code": http.StatusBadRequest})
+			return
+		}
+	}
+
+	targetDB := db.GetTargetDB()
+	if targetDB == nil {
+		util.Err(c, fmt.Errorf("targetDB 未初始化"))
+		return
+	}
+
+	// 调用方无权访问的站点同样按不存在处理
+	var site models.TSite
+	siteQuery := scopeSiteQuery(c, targetDB, targetDB.Table(models.TableNameTSite).Where("ID = ?", siteId))
+	if err := siteQuery.Take(&site).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			util.Err(c, gin.H{"error": fmt.Sprintf("站点不存在: %s", idStr), "code": http.StatusNotFound})
+			return
+		}
+		util.Err(c, fmt.Errorf("查询站点失败: %v", err))
+		return
+	}
+	domain := getSiteDomainName(siteId)
+
+	// 栏目列表页，地址规则与 getColumns 一致
+	var columns []models.TColumn
+	if err := scopeColumnQuery(c, targetDB.Table(models.TableNameTColumn)).
+		Select("id, siteId, urlName, link").
+		Where("siteId = ? AND id != 1", siteId).
+		Order("sort ASC, id ASC").
+		Find(&columns).Error; err != nil {
+		util.Err(c, fmt.Errorf("查询站点栏目失败: %v", err))
+		return
+	}
+	columnUrls := make([]sitemapUrl, 0, len(columns))
+	for _, col := range columns {
+		link := col.Link
+		if link == "" {
+			link = buildColumnUrl(domain, col.UrlName)
+		}
+		if link != "" {
+			columnUrls = append(columnUrls, sitemapUrl{Loc: absoluteUrl(domain, link)})
+		}
+	}
+
+	// 文章在每个所属栏目下各有一条 article_dynamic，按 articleId 去重，每篇文章只输出一个地址
+	articleQuery := func() *gorm.DB {
+		q := targetDB.Table(models.TableNameArticleDynamic+" AS d").
+			Joins("JOIN "+models.TableNameArticleStatic+" AS s ON s.articleId = d.articleId").
+			Where("d.siteId = ? AND d.url IS NOT NULL AND d.url <> ''", idStr)
+		return scopeDynamicQuery(c, q, "d")
+	}
+	var articleTotal int64
+	if err := articleQuery().Select("COUNT(DISTINCT d.articleId)").Scan(&articleTotal).Error; err != nil {
+		util.Err(c, fmt.Errorf("统计站点文章失败: %v", err))
+		return
+	}
+
+	total := int64(len(columnUrls)) + articleTotal
+	pages := int((total + sitemapMaxUrls - 1) / sitemapMaxUrls)
+	if pages > 1 && page == 0 {
+		// 超过单文件上限，返回 sitemap index
+		index := sitemapIndex{Xmlns: sitemapNamespace}
+		base := sitemapBaseUrl(c)
+		for p := 1; p <= pages; p++ {
+			index.Sitemaps = append(index.Sitemaps, sitemapPointer{Loc: fmt.Sprintf("%s?page=%d", base, p)})
+		}
+		renderXML(c, index)
+		return
+	}
+	if page == 0 {
+		page = 1
+	}
+	if page > 1 && page > pages {
+		util.Err(c, gin.H{"error": fmt.Sprintf("page 超出范围: %d", page), "code": http.StatusNotFound})
+		return
+	}
+
+	// 栏目在前、文章在后，按全局序号切分为每页 sitemapMaxUrls 条
+	start := int64(page-1) * sitemapMaxUrls
+	end := start + sitemapMaxUrls
+	urlSet := sitemapUrlSet{Xmlns: sitemapNamespace, Urls: make([]sitemapUrl, 0)}
+	if start < int64(len(columnUrls)) {
+		stop := end
+		if stop > int64(len(columnUrls)) {
+			stop = int64(len(columnUrls))
+		}
+		urlSet.Urls = append(urlSet.Urls, columnUrls[start:stop]...)
+	}
+	if remaining := sitemapMaxUrls - len(urlSet.Urls); remaining > 0 {
+		offset := start - int64(len(columnUrls))
+		if offset < 0 {
+			offset = 0
+		}
+		var rows []sitemapArticleRow
+		if err := articleQuery().
+			// 没有修改时间的文章以发布时间作为 lastmod
+			Select("MIN(d.url) AS url, MAX(COALESCE(s.lastModifyTime, s.publishTime)) AS lastModifyTime").
+			Group("d.articleId").
+			Order("d.articleId ASC").
+			Offset(int(offset)).
+			Limit(remaining).
+			Scan(&rows).Error; err != nil {
+			util.Err(c, fmt.Errorf("查询站点文章失败: %v", err))
+			return
+		}
+		for _, r := range rows {
+			u := sitemapUrl{Loc: absoluteUrl(domain, r.Url)}
+			if r.LastModifyTime != nil {
+				u.LastMod = r.LastModifyTime.Format(time.RFC3339)
+			}
+			urlSet.Urls = append(urlSet.Urls, u)
+		}
+	}
+	renderXML(c, urlSet)
+}
+
+// sitemapBaseUrl 当前请求的完整地址（不含查询参数），用于生成 sitemap index 中的分页地址
+func sitemapBaseUrl(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host + c.Request.URL.Path
+}
+
+// renderXML 输出带 XML 声明的文档
+func renderXML(c *gin.Context, v interface{}) {
+	out, err := xml.Marshal(v)
+	if err != nil {
+		util.Err(c, fmt.Errorf("生成 XML 失败: %v", err))
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", append([]byte(xml.Header), out...))
+}
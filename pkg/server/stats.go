@@ -0,0 +1,207 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// statsDimension 统计分组维度
+type statsDimension struct {
+	selects  []string // SELECT 子句，别名与 statsRow 的列名一致
+	groupBy  string   // GROUP BY 表达式
+	needJoin bool     // 是否需要关联 article_dynamic
+}
+
+// statsDimensions 允许的分组维度（请求参数 -> SQL），s 为 article_static，d 为 article_dynamic
+var statsDimensions = map[string]statsDimension{
+	"siteId":         {selects: []string{"d.siteId AS siteId", "MAX(d.siteName) AS siteName"}, groupBy: "d.siteId", needJoin: true},
+	"columnId":       {selects: []string{"d.columnId AS columnId", "MAX(d.columnName) AS columnName"}, groupBy: "d.columnId", needJoin: true},
+	"year":           {selects: []string{"DATE_FORMAT(s.publishTime, '%Y') AS year"}, groupBy: "DATE_FORMAT(s.publishTime, '%Y')"},
+	"month":          {selects: []string{"DATE_FORMAT(s.publishTime, '%Y-%m') AS month"}, groupBy: "DATE_FORMAT(s.publishTime, '%Y-%m')"},
+	"publisherName":  {selects: []string{"s.publisherName AS publisherName"}, groupBy: "s.publisherName"},
+	"publishOrgName": {selects: []string{"s.publishOrgName AS publishOrgName"}, groupBy: "s.publishOrgName"},
+}
+
+// statsRow 统计查询结果，未参与分组的列为空
+type statsRow struct {
+	SiteId         string `gorm:"column:siteId"`
+	SiteName       string `gorm:"column:siteName"`
+	ColumnId       string `gorm:"column:columnId"`
+	ColumnName     string `gorm:"column:columnName"`
+	Year           string `gorm:"column:year"`
+	Month          string `gorm:"column:month"`
+	PublisherName  string `gorm:"column:publisherName"`
+	PublishOrgName string `gorm:"column:publishOrgName"`
+	Count          int64  `gorm:"column:count"`
+}
+
+// toItem 只输出参与分组的字段和文章数
+func (r statsRow) toItem(dims []string) gin.H {
+	item := gin.H{"count": r.Count}
+	for _, d := range dims {
+		switch d {
+		case "siteId":
+			item["siteId"] = r.SiteId
+			item["siteName"] = r.SiteName
+		case "columnId":
+			item["columnId"] = r.ColumnId
+			item["columnName"] = r.ColumnName
+		case "year":
+			item["year"] = r.Year
+		case "month":
+			item["month"] = r.Month
+		case "publisherName":
+			item["publisherName"] = r.PublisherName
+		case "publishOrgName":
+			item["publishOrgName"] = r.PublishOrgName
+		}
+	}
+	return item
+}
+
+// GetStats 文章数量统计
+// @Summary      文章数量统计
+// @Description  按站点、栏目、发布年月、发布人、发布单位分组统计文章数，同一文章在一个分组内只计一次
+// @Tags         articles
+// @Produce      json
+// @Param        groupBy   query  string  false  "分组维度，逗号分隔: siteId(默认)、columnId、year、month、publisherName、publishOrgName"
+// @Param        siteId    query  string  false  "站点ID，逗号分隔"
+// @Param        columnId  query  string  false  "栏目ID，逗号分隔，同时传 siteId 时只看 columnId"
+// @Param        includeChildren query bool false "为 true 时包含 columnId 的全部子孙栏目"
+// @Param        startTime query  string  false  "发布时间起，格式: 2025-01-01"
+// @Param        endTime   query  string  false  "发布时间止，格式: 2025-01-01"
+// @Success      200  {object}  util.Response
+// @Failure      400  {object}  util.Response
+// @Router       /api/v1/webplus/stats [get]
+// @Router       /api/v1/webplus/stats [post]
+func (h *Handler) GetStats(c *gin.Context) {
+	groupByStr := util.GetParam(c, "groupBy")
+	if groupByStr == "" {
+		groupByStr = "siteId"
+	}
+	var dims []string
+	seen := make(map[string]bool)
+	for _, p := range strings.Split(groupByStr, ",") {
+		d := strings.TrimSpace(p)
+		if d == "" || seen[d] {
+			continue
+		}
+		if _, ok := statsDimensions[d]; !ok {
+			util.Err(c, gin.H{"error": fmt.Sprintf("不支持的 groupBy: %s", d), "code": http.StatusBadRequest})
+			return
+		}
+		seen[d] = true
+		dims = append(dims, d)
+	}
+
+	startTime, endTime, err := parseTimeRange(c)
+	if err != nil {
+		util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+		return
+	}
+
+	targetDB := db.GetTargetDB()
+	if targetDB == nil {
+		util.Err(c, fmt.Errorf("targetDB 未初始化"))
+		return
+	}
+
+	// 站点 / 栏目过滤条件作用在 article_dynamic 上
+	var (
+		columnIds []int64
+		siteIds   []string
+	)
+	if columnIdStr := util.GetParam(c, "columnId"); columnIdStr != "" {
+		columnIds, _ = parseIDList(columnIdStr)
+		if len(columnIds) == 0 {
+			util.Ok(c, gin.H{"found": false, "items": []gin.H{}, "total": 0})
+			return
+		}
+		if includeChildren, _ := strconv.ParseBool(util.GetParam(c, "includeChildren")); includeChildren {
+			if columnIds, err = expandColumnIds(targetDB, columnIds); err != nil {
+				util.Err(c, err)
+				return
+			}
+		}
+	} else if siteIdStr := util.GetParam(c, "siteId"); siteIdStr != "" {
+		ids, _ := parseIDList(siteIdStr)
+		if len(ids) == 0 {
+			util.Ok(c, gin.H{"found": false, "items": []gin.H{}, "total": 0})
+			return
+		}
+		for _, id := range ids {
+			siteIds = append(siteIds, strconv.FormatInt(id, 10))
+		}
+	}
+
+	needJoin := len(columnIds) > 0 || len(siteIds) > 0 || currentClient(c).restricted()
+	for _, d := range dims {
+		needJoin = needJoin || statsDimensions[d].needJoin
+	}
+
+	baseQuery := func() *gorm.DB {
+		q := targetDB.Table(models.TableNameArticleStatic + " AS s")
+		if needJoin {
+			q = q.Joins("JOIN " + models.TableNameArticleDynamic + " AS d ON d.articleId = s.articleId")
+		}
+		if len(columnIds) > 0 {
+			q = q.Where("d.columnId IN ?", columnIds)
+		}
+		if len(siteIds) > 0 {
+			q = q.Where("d.siteId IN ?", siteIds)
+		}
+		q = scopeDynamicQuery(c, q, "d")
+		if startTime != nil {
+			q = q.Where("s.publishTime >= ?", *startTime)
+		}
+		if endTime != nil {
+			q = q.Where("s.publishTime <= ?", *endTime)
+		}
+		return q
+	}
+
+	// 满足条件的文章总数（去重）
+	var total int64
+	if err := baseQuery().Select("COUNT(DISTINCT s.articleId)").Scan(&total).Error; err != nil {
+		util.Err(c, fmt.Errorf("统计文章总数失败: %v", err))
+		return
+	}
+
+	selects := make([]string, 0, len(dims)+1)
+	groups := make([]string, 0, len(dims))
+	for _, d := range dims {
+		selects = append(selects, statsDimensions[d].selects...)
+		groups = append(groups, statsDimensions[d].groupBy)
+	}
+	selects = append(selects, "COUNT(DISTINCT s.articleId) AS count")
+
+	var rows []statsRow
+	if err := baseQuery().
+		Select(strings.Join(selects, ", ")).
+		Group(strings.Join(groups, ", ")).
+		Order(strings.Join(groups, ", ")).
+		Scan(&rows).Error; err != nil {
+		util.Err(c, fmt.Errorf("统计文章数量失败: %v", err))
+		return
+	}
+
+	items := make([]gin.H, 0, len(rows))
+	for _, r := range rows {
+		items = append(items, r.toItem(dims))
+	}
+
+	util.Ok(c, gin.H{
+		"found":   len(items) > 0,
+		"groupBy": dims,
+		"items":   items,
+		"total":   total,
+	})
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// anonymousClient 未启用认证时统计调用量使用的调用方名称
+const anonymousClient = "anonymous"
+
+// usageFlushInterval 调用量写入 api_usage 表的间隔
+const usageFlushInterval = 30 * time.Second
+
+type usageKey struct {
+	client string
+	date   string
+}
+
+// usageTracker 统计每个调用方每日的请求次数，内存中累加，定期写入 api_usage 表
+type usageTracker struct {
+	mu       sync.Mutex
+	loaded   map[usageKey]int64 // 当日已写入数据库的次数（首次访问时从库中加载，包含其他实例写入的次数）
+	pending  map[usageKey]int64 // 尚未写入数据库的次数
+	inflight map[usageKey]int64 // 正在写入数据库的次数
+	loading  singleflight.Group // 同一调用方同一天的并发首次请求只查询一次数据库
+}
+
+func newUsageTracker() *usageTracker {
+	return &usageTracker{
+		loaded:   make(map[usageKey]int64),
+		pending:  make(map[usageKey]int64),
+		inflight: make(map[usageKey]int64),
+	}
+}
+
+// count 返回调用方当日的请求次数，加载已有次数失败时返回错误
+func (u *usageTracker) count(client string, now time.Time) (int64, error) {
+	key := usageKey{client: client, date: now.Format(time.DateOnly)}
+	if err := u.ensureLoaded(key); err != nil {
+		return 0, err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.loaded[key] + u.pending[key] + u.inflight[key], nil
+}
+
+// add 调用方当日请求次数加一；加载已有次数失败时不计数，避免与之后的加载重复统计
+func (u *usageTracker) add(client string, now time.Time) {
+	key := usageKey{client: client, date: now.Format(time.DateOnly)}
+	if err := u.ensureLoaded(key); err != nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.pending[key]++
+}
+
+// ensureLoaded 首次访问某调用方当日的计数时，从数据库加载已有次数；查询时不持有锁，不阻塞其他调用方的请求。
+// 该 key 的 pending 只在加载完成后才会累加，flush 不会在加载期间写入它的次数；加载失败时不标记为已加载，下次请求重试
+func (u *usageTracker) ensureLoaded(key usageKey) error {
+	u.mu.Lock()
+	_, ok := u.loaded[key]
+	u.mu.Unlock()
+	if ok {
+		return nil
+	}
+	_, err, _ := u.loading.Do(key.client+"|"+key.date, func() (interface{}, error) {
+		var n int64
+		if targetDB := db.GetTargetDB(); targetDB != nil {
+			if err := targetDB.Table(models.TableNameApiUsage).
+				Select("requestCount").
+				Where("clientName = ? AND date = ?", key.client, key.date).
+				Scan(&n).Error; err != nil {
+				zap.S().Warnf("加载调用量失败: client=%s, date=%s, err=%v", key.client, key.date, err)
+				return nil, fmt.Errorf("加载调用量失败: %v", err)
+			}
+		}
+		u.mu.Lock()
+		if _, ok := u.loaded[key]; !ok {
+			u.loaded[key] = n
+		}
+		u.mu.Unlock()
+		return nil, nil
+	})
+	return err
+}
+
+// flush 将未写入的次数累加到 api_usage 表，失败的部分留待下次写入
+func (u *usageTracker) flush() {
+	targetDB := db.GetTargetDB()
+	if targetDB == nil {
+		return
+	}
+	u.mu.Lock()
+	pending := u.pending
+	u.pending = make(map[usageKey]int64)
+	for key, n := range pending {
+		u.inflight[key] += n
+	}
+	u.mu.Unlock()
+
+	today := time.Now().Format(time.DateOnly)
+	for key, n := range pending {
+		row := models.ApiUsage{ClientName: key.client, Date: key.date, Count: n}
+		err := targetDB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "clientName"}, {Name: "date"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"requestCount": gorm.Expr("requestCount + ?", n)}),
+		}).Create(&row).Error
+
+		u.mu.Lock()
+		if u.inflight[key] -= n; u.inflight[key] <= 0 {
+			delete(u.inflight, key)
+		}
+		if err != nil {
+			zap.S().Warnf("写入调用量失败: client=%s, date=%s, err=%v", key.client, key.date, err)
+			u.pending[key] += n
+		} else if _, ok := u.loaded[key]; ok {
+			u.loaded[key] += n
+		}
+		u.mu.Unlock()
+	}
+
+	// 清理往日的计数
+	u.mu.Lock()
+	for key := range u.loaded {
+		if key.date != today && u.pending[key] == 0 {
+			delete(u.loaded, key)
+		}
+	}
+	u.mu.Unlock()
+}
+
+// run 定期写入调用量，ctx 结束时写入剩余部分后返回
+func (u *usageTracker) run(ctx context.Context) error {
+	ticker := time.NewTicker(usageFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			u.flush()
+			return nil
+		case <-ticker.C:
+			u.flush()
+		}
+	}
+}
+
+// GetUsage 调用量统计
+// @Summary      调用量统计
+// @Description  按日返回调用方的请求次数；启用认证时只能查询当前 API Key 自己的调用量
+// @Tags         usage
+// @Produce      json
+// @Param        startDate  query  string  false  "开始日期，格式: 2025-01-01，默认 30 天前"
+// @Param        endDate    query  string  false  "结束日期，格式: 2025-01-01，默认今天"
+// @Param        clientName query  string  false  "调用方名称，未启用认证时可用"
+// @Success      200  {object}  util.Response
+// @Failure      400  {object}  util.Response
+// @Router       /api/v1/webplus/usage [get]
+func (h *Handler) GetUsage(c *gin.Context) {
+	now := time.Now()
+	startDate := util.GetParam(c, "startDate")
+	if startDate == "" {
+		startDate = now.AddDate(0, 0, -30).Format(time.DateOnly)
+	}
+	endDate := util.GetParam(c, "endDate")
+	if endDate == "" {
+		endDate = now.Format(time.DateOnly)
+	}
+	for _, d := range []string{startDate, endDate} {
+		if _, err := time.Parse(time.DateOnly, d); err != nil {
+			util.Err(c, gin.H{"error": fmt.Sprintf("日期格式错误: %s", d), "code": http.StatusBadRequest})
+			return
+		}
+	}
+	client := util.GetParam(c, "clientName")
+	if cl := currentClient(c); cl != nil {
+		client = cl.Name
+	}
+
+	targetDB := db.GetTargetDB()
+	if targetDB == nil {
+		util.Err(c, fmt.Errorf("targetDB 未初始化"))
+		return
+	}
+
+	// 先写入内存中的计数，保证返回最新数据
+	if h.usage != nil {
+		h.usage.flush()
+	}
+
+	query := targetDB.Table(models.TableNameApiUsage).Where("date >= ? AND date <= ?", startDate, endDate)
+	if client != "" {
+		query = query.Where("clientName = ?", client)
+	}
+	var rows []models.ApiUsage
+	if err := query.Order("date ASC, clientName ASC").Find(&rows).Error; err != nil {
+		util.Err(c, fmt.Errorf("查询调用量失败: %v", err))
+		return
+	}
+
+	totals := make(map[string]int64)
+	for _, r := range rows {
+		totals[r.ClientName] += r.Count
+	}
+	summary := make([]gin.H, 0, len(totals))
+	for name, total := range totals {
+		summary = append(summary, gin.H{"clientName": name, "total": total})
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		return summary[i]["clientName"].(string) < summary[j]["clientName"].(string)
+	})
+
+	util.Ok(c, gin.H{
+		"startDate": startDate,
+		"endDate":   endDate,
+		"items":     rows,
+		"summary":   summary,
+	})
+}
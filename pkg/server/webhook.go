@@ -0,0 +1,333 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/models"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+)
+
+// WebhookConfig webhook 投递配置，订阅通过 /api/v1/webplus/admin/webhooks 管理
+type WebhookConfig struct {
+	// Enabled 是否投递 webhook
+	Enabled bool `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	// Timeout 单次投递的超时时间（秒），默认 10
+	Timeout int `json:"timeout,omitempty" yaml:"timeout,omitempty" mapstructure:"timeout"`
+	// MaxAttempts 最多投递次数，超过后标记为 failed，默认 8
+	MaxAttempts int `json:"max_attempts,omitempty" yaml:"maxAttempts,omitempty" mapstructure:"maxAttempts"`
+	// RetryBase 第一次重试的等待时间（秒），之后每次翻倍，最长 1 小时，默认 30
+	RetryBase int `json:"retry_base,omitempty" yaml:"retryBase,omitempty" mapstructure:"retryBase"`
+	// Workers 并发投递数，默认 4
+	Workers int `json:"workers,omitempty" yaml:"workers,omitempty" mapstructure:"workers"`
+}
+
+const (
+	// webhookPollInterval 检查到期重试的间隔
+	webhookPollInterval = 5 * time.Second
+	// webhookBatchSize 每轮最多投递的记录数
+	webhookBatchSize = 100
+	// webhookMaxRetryDelay 重试等待时间上限
+	webhookMaxRetryDelay = time.Hour
+	// webhookMaxErrorLen lastError 保存的最大长度
+	webhookMaxErrorLen = 1000
+)
+
+// webhook 请求头
+const (
+	webhookHeaderEvent     = "X-Webplus-Event"
+	webhookHeaderDelivery  = "X-Webplus-Delivery"
+	webhookHeaderTimestamp = "X-Webplus-Timestamp"
+	webhookHeaderSignature = "X-Webplus-Signature"
+)
+
+// webhookDispatcher 按 webhook_delivery 表投递和重试，投递状态都保存在数据库中，重启后继续
+type webhookDispatcher struct {
+	client      *http.Client
+	maxAttempts int
+	retryBase   time.Duration
+	workers     int
+	wake        chan struct{}
+}
+
+var (
+	webhooks     *webhookDispatcher
+	webhooksOnce sync.Once
+)
+
+// initWebhookDispatcher 按配置创建进程内唯一的投递器，未启用时返回 nil
+func initWebhookDispatcher(cfg *WebhookConfig) *webhookDispatcher {
+	webhooksOnce.Do(func() {
+		if cfg == nil || !cfg.Enabled {
+			return
+		}
+		d := &webhookDispatcher{
+			client:      &http.Client{Timeout: 10 * time.Second},
+			maxAttempts: 8,
+			retryBase:   30 * time.Second,
+			workers:     4,
+			wake:        make(chan struct{}, 1),
+		}
+		if cfg.Timeout > 0 {
+			d.client.Timeout = time.Duration(cfg.Timeout) * time.Second
+		}
+		if cfg.MaxAttempts > 0 {
+			d.maxAttempts = cfg.MaxAttempts
+		}
+		if cfg.RetryBase > 0 {
+			d.retryBase = time.Duration(cfg.RetryBase) * time.Second
+		}
+		if cfg.Workers > 0 {
+			d.workers = cfg.Workers
+		}
+		webhooks = d
+		zap.S().Infof("webhook 投递已启用，最多投递 %d 次", d.maxAttempts)
+	})
+	return webhooks
+}
+
+// notify 唤醒投递循环，不阻塞
+func (d *webhookDispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run 投递到期的记录，有新记录时立即投递，否则每 webhookPollInterval 检查一次，ctx 结束时返回
+func (d *webhookDispatcher) run(ctx context.Context) error {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-d.wake:
+		}
+		// 一轮取满时可能还有到期记录，继续投递
+		for ctx.Err() == nil {
+			if d.dispatchDue(ctx) < webhookBatchSize {
+				break
+			}
+		}
+	}
+}
+
+// dispatchDue 并发投递一批到期的记录，返回本批记录数
+func (d *webhookDispatcher) dispatchDue(ctx context.Context) int {
+	targetDB := db.GetTargetDB()
+	if targetDB == nil {
+		return 0
+	}
+	var due []models.WebhookDelivery
+	if err := targetDB.Where("status = ? AND nextAttemptAt <= ?", models.WebhookDeliveryPending, time.Now()).
+		Order("nextAttemptAt ASC, id ASC").
+		Limit(webhookBatchSize).
+		Find(&due).Error; err != nil {
+		zap.S().Warnf("查询待投递的 webhook 失败: %v", err)
+		return 0
+	}
+	if len(due) == 0 {
+		return 0
+	}
+
+	subIds := lo.Uniq(lo.Map(due, func(d models.WebhookDelivery, _ int) int64 { return d.SubscriptionId }))
+	var subs []models.WebhookSubscription
+	if err := targetDB.Where("id IN ?", subIds).Find(&subs).Error; err != nil {
+		zap.S().Warnf("查询 webhook 订阅失败: %v", err)
+		return 0
+	}
+	subMap := lo.KeyBy(subs, func(s models.WebhookSubscription) int64 { return s.Id })
+
+	g, c := errgroup.WithContext(ctx)
+	g.SetLimit(d.workers)
+	for i := range due {
+		delivery := due[i]
+		g.Go(func() error {
+			d.attempt(c, targetDB, &delivery, subMap[delivery.SubscriptionId])
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return len(due)
+}
+
+// attempt 投递一条记录并更新状态；多个实例同时运行时，先按 attempts 抢占，抢占失败说明已被其他实例处理
+func (d *webhookDispatcher) attempt(ctx context.Context, targetDB *gorm.DB, delivery *models.WebhookDelivery, sub models.WebhookSubscription) {
+	now := time.Now()
+	lease := now.Add(2 * d.client.Timeout)
+	res := targetDB.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", delivery.Id, models.WebhookDeliveryPending, delivery.Attempts).
+		Updates(map[string]interface{}{"attempts": delivery.Attempts + 1, "nextAttemptAt": lease})
+	if res.Error != nil {
+		zap.S().Warnf("抢占 webhook 投递失败: id=%d, err=%v", delivery.Id, res.Error)
+		return
+	}
+	if res.RowsAffected == 0 {
+		return
+	}
+	delivery.Attempts++
+
+	updates := map[string]interface{}{}
+	switch {
+	case sub.Id == 0 || !sub.Enabled:
+		updates["status"] = models.WebhookDeliveryFailed
+		updates["lastError"] = "订阅不存在或已停用"
+		updates["nextAttemptAt"] = nil
+	default:
+		code, err := d.send(ctx, sub, delivery)
+		updates["responseCode"] = code
+		switch {
+		case err == nil:
+			updates["status"] = models.WebhookDeliverySuccess
+			updates["lastError"] = ""
+			updates["nextAttemptAt"] = nil
+			updates["deliveredAt"] = time.Now()
+		case delivery.Attempts >= d.maxAttempts:
+			updates["status"] = models.WebhookDeliveryFailed
+			updates["lastError"] = truncateError(err)
+			updates["nextAttemptAt"] = nil
+			zap.S().Warnf("webhook 投递失败且不再重试: id=%d, url=%s, err=%v", delivery.Id, sub.Url, err)
+		default:
+			updates["lastError"] = truncateError(err)
+			updates["nextAttemptAt"] = time.Now().Add(d.retryDelay(delivery.Attempts))
+			zap.S().Debugf("webhook 投递失败，稍后重试: id=%d, attempts=%d, err=%v", delivery.Id, delivery.Attempts, err)
+		}
+	}
+	if err := targetDB.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.Id).Updates(updates).Error; err != nil {
+		zap.S().Warnf("更新 webhook 投递状态失败: id=%d, err=%v", delivery.Id, err)
+	}
+}
+
+// retryDelay 第 attempts 次失败后的等待时间：retryBase * 2^(attempts-1)，最长 webhookMaxRetryDelay
+func (d *webhookDispatcher) retryDelay(attempts int) time.Duration {
+	delay := d.retryBase
+	for i := 1; i < attempts && delay < webhookMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookMaxRetryDelay)
+}
+
+// send 发送一次投递，对方返回 2xx 时成功
+func (d *webhookDispatcher) send(ctx context.Context, sub models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookHeaderEvent, delivery.Op)
+	req.Header.Set(webhookHeaderDelivery, strconv.FormatInt(delivery.Id, 10))
+	req.Header.Set(webhookHeaderTimestamp, timestamp)
+	req.Header.Set(webhookHeaderSignature, "sha256="+signWebhook(sub.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("对方返回 HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhook 计算签名：HMAC-SHA256(secret, timestamp + "." + body) 的十六进制，接收方按同样方式校验
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// truncateError 截断错误信息以写入 lastError
+func truncateError(err error) string {
+	msg := err.Error()
+	if len(msg) > webhookMaxErrorLen {
+		msg = msg[:webhookMaxErrorLen]
+	}
+	return msg
+}
+
+// webhookMatches 订阅是否关注该变更
+func webhookMatches(sub models.WebhookSubscription, change *models.ArticleChangeLog) bool {
+	return changeMatches(splitIds(sub.SiteIds), splitIds(sub.ColumnIds), splitIds(sub.Ops), change)
+}
+
+// splitIds 拆分逗号分隔的字符串，忽略空项
+func splitIds(s string) []string {
+	var ids []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			ids = append(ids, part)
+		}
+	}
+	return ids
+}
+
+// newWebhookDelivery 为订阅创建一条待投递记录，请求体为与 /changes 相同的变更事件
+func newWebhookDelivery(sub models.WebhookSubscription, change *models.ArticleChangeLog) (*models.WebhookDelivery, error) {
+	payload, err := json.Marshal(newChangeEvent(*change))
+	if err != nil {
+		return nil, fmt.Errorf("序列化变更事件失败: %v", err)
+	}
+	now := time.Now()
+	return &models.WebhookDelivery{
+		SubscriptionId: sub.Id,
+		ChangeSeq:      change.Seq,
+		ArticleId:      change.ArticleId,
+		Op:             change.Op,
+		Payload:        string(payload),
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  &now,
+	}, nil
+}
+
+// enqueueWebhookDeliveries 在写入变更日志的事务中为匹配的订阅写入待投递记录（outbox），失败时整个事务回滚，
+// 不会出现数据已变更而 webhook 投递记录丢失的情况；未启用 webhook 时跳过
+func enqueueWebhookDeliveries(tx *gorm.DB, change *models.ArticleChangeLog) error {
+	if webhooks == nil {
+		return nil
+	}
+	var subs []models.WebhookSubscription
+	if err := tx.Where("enabled = ?", true).Find(&subs).Error; err != nil {
+		return fmt.Errorf("查询 webhook 订阅失败: %v", err)
+	}
+	var deliveries []*models.WebhookDelivery
+	for _, sub := range subs {
+		if !webhookMatches(sub, change) {
+			continue
+		}
+		delivery, err := newWebhookDelivery(sub, change)
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := tx.Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("写入 webhook 投递记录失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,403 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/models"
+	"webplus-openapi/pkg/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+// webhook 投递记录查询的默认、最大条数
+const (
+	defaultWebhookDeliveriesLimit = 50
+	maxWebhookDeliveriesLimit     = 500
+)
+
+// webhookOps 可订阅的变更类型
+var webhookOps = []string{models.ArticleChangeUpsert, models.ArticleChangeDelete, models.ArticleChangeColumnAdd, models.ArticleChangeColumnRemove}
+
+// WebhookRequest 创建、修改 webhook 订阅的请求体
+type WebhookRequest struct {
+	Name      string   `json:"name"`
+	Url       string   `json:"url"`                 // 接收地址，http 或 https
+	Secret    string   `json:"secret,omitempty"`    // 签名密钥，创建时为空则自动生成；修改时为空则保持不变
+	SiteIds   []string `json:"siteIds,omitempty"`   // 站点ID，与 columnIds 取并集，都为空时不限制
+	ColumnIds []string `json:"columnIds,omitempty"` // 栏目ID
+	Ops       []string `json:"ops,omitempty"`       // 变更类型：upsert、delete、column_add、column_remove，为空时全部订阅
+	Enabled   *bool    `json:"enabled,omitempty"`   // 是否启用，默认 true
+}
+
+// apply 校验请求并写入订阅
+func (r *WebhookRequest) apply(sub *models.WebhookSubscription) error {
+	u, err := url.Parse(strings.TrimSpace(r.Url))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url 必须为 http 或 https 地址: %s", r.Url)
+	}
+	for _, op := range r.Ops {
+		if !lo.Contains(webhookOps, op) {
+			return fmt.Errorf("不支持的 ops: %s，可选值为 %s", op, strings.Join(webhookOps, ","))
+		}
+	}
+	for _, id := range append(append([]string{}, r.SiteIds...), r.ColumnIds...) {
+		if _, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64); err != nil {
+			return fmt.Errorf("siteIds、columnIds 必须为数字: %s", id)
+		}
+	}
+	sub.Name = strings.TrimSpace(r.Name)
+	sub.Url = u.String()
+	sub.SiteIds = strings.Join(lo.Map(r.SiteIds, func(s string, _ int) string { return strings.TrimSpace(s) }), ",")
+	sub.ColumnIds = strings.Join(lo.Map(r.ColumnIds, func(s string, _ int) string { return strings.TrimSpace(s) }), ",")
+	sub.Ops = strings.Join(lo.Uniq(r.Ops), ",")
+	sub.Enabled = r.Enabled == nil || *r.Enabled
+	if r.Secret != "" {
+		sub.Secret = r.Secret
+	}
+	return nil
+}
+
+// newWebhookSecret 生成随机签名密钥
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成签名密钥失败: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// bindWebhookRequest 解析 JSON 请求体
+func bindWebhookRequest(c *gin.Context) (*WebhookRequest, bool) {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.Err(c, gin.H{"error": fmt.Sprintf("请求体格式错误: %v", err), "code": http.StatusBadRequest})
+		return nil, false
+	}
+	return &req, true
+}
+
+// findWebhook 按路径参数 id 查询订阅，不存在时返回 404
+func findWebhook(c *gin.Context, targetDB *gorm.DB) (*models.WebhookSubscription, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		util.Err(c, gin.H{"error": fmt.Sprintf("id 必须为数字: %s", c.Param("id")), "code": http.StatusBadRequest})
+		return nil, false
+	}
+	var sub models.WebhookSubscription
+	if err := targetDB.Where("id = ?", id).Take(&sub).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			util.Err(c, gin.H{"error": fmt.Sprintf("webhook 不存在: %d", id), "code": http.StatusNotFound})
+		} else {
+			util.Err(c, fmt.Errorf("查询 webhook 失败: %v", err))
+		}
+		return nil, false
+	}
+	return &sub, true
+}
+
+// adminTargetDB 返回 targetDB，未初始化时写入错误响应
+func adminTargetDB(c *gin.Context) (*gorm.DB, bool) {
+	targetDB := db.GetTargetDB()
+	if targetDB == nil {
+		util.Err(c, fmt.Errorf("targetDB 未初始化"))
+		return nil, false
+	}
+	return targetDB, true
+}
+
+// ListWebhooks webhook 订阅列表
+// @Summary      webhook 订阅列表
+// @Description  需要 admin API Key；签名密钥不返回
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  util.Response
+// @Failure      403  {object}  util.Response
+// @Router       /api/v1/webplus/admin/webhooks [get]
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	targetDB, ok := adminTargetDB(c)
+	if !ok {
+		return
+	}
+	var subs []models.WebhookSubscription
+	if err := targetDB.Order("id ASC").Find(&subs).Error; err != nil {
+		util.Err(c, fmt.Errorf("查询 webhook 失败: %v", err))
+		return
+	}
+	util.Ok(c, gin.H{"items": subs})
+}
+
+// CreateWebhook 创建 webhook 订阅
+// @Summary      创建 webhook 订阅
+// @Description  需要 admin API Key。文章变更后向 url POST 变更事件（与 /changes 相同），请求头 X-Webplus-Signature 为 sha256=HMAC-SHA256(secret, X-Webplus-Timestamp + "." + 请求体)；返回 2xx 视为成功，否则按指数退避重试。secret 只在创建时返回
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        body  body  WebhookRequest  true  "订阅"
+// @Success      200  {object}  util.Response
+// @Failure      400  {object}  util.Response
+// @Failure      403  {object}  util.Response
+// @Router       /api/v1/webplus/admin/webhooks [post]
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	req, ok := bindWebhookRequest(c)
+	if !ok {
+		return
+	}
+	var sub models.WebhookSubscription
+	if err := req.apply(&sub); err != nil {
+		util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+		return
+	}
+	if sub.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			util.Err(c, err)
+			return
+		}
+		sub.Secret = secret
+	}
+	targetDB, ok := adminTargetDB(c)
+	if !ok {
+		return
+	}
+	if err := targetDB.Create(&sub).Error; err != nil {
+		util.Err(c, fmt.Errorf("创建 webhook 失败: %v", err))
+		return
+	}
+	util.Ok(c, gin.H{"item": sub, "secret": sub.Secret})
+}
+
+// UpdateWebhook 修改 webhook 订阅
+// @Summary      修改 webhook 订阅
+// @Description  需要 admin API Key；整体替换订阅配置，secret 为空时保持不变
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id    path  int             true  "订阅ID"
+// @Param        body  body  WebhookRequest  true  "订阅"
+// @Success      200  {object}  util.Response
+// @Failure      400  {object}  util.Response
+// @Failure      404  {object}  util.Response
+// @Router       /api/v1/webplus/admin/webhooks/{id} [put]
+func (h *Handler) UpdateWebhook(c *gin.Context) {
+	targetDB, ok := adminTargetDB(c)
+	if !ok {
+		return
+	}
+	sub, ok := findWebhook(c, targetDB)
+	if !ok {
+		return
+	}
+	req, ok := bindWebhookRequest(c)
+	if !ok {
+		return
+	}
+	if err := req.apply(sub); err != nil {
+		util.Err(c, gin.H{"error": err.Error(), "code": http.StatusBadRequest})
+		return
+	}
+	if err := targetDB.Save(sub).Error; err != nil {
+		util.Err(c, fmt.Errorf("修改 webhook 失败: %v", err))
+		return
+	}
+	util.Ok(c, gin.H{"item": sub})
+}
+
+// DeleteWebhook 删除 webhook 订阅及其投递记录
+// @Summary      删除 webhook 订阅
+// @Description  需要 admin API Key；同时删除该订阅的投递记录
+// @Tags         admin
+// @Produce      json
+// @Param        id  path  int  true  "订阅ID"
+// @Success      200  {object}  util.Response
+// @Failure      404  {object}  util.Response
+// @Router       /api/v1/webplus/admin/webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	targetDB, ok := adminTargetDB(c)
+	if !ok {
+		return
+	}
+	sub, ok := findWebhook(c, targetDB)
+	if !ok {
+		return
+	}
+	err := targetDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscriptionId = ?", sub.Id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(sub).Error
+	})
+	if err != nil {
+		util.Err(c, fmt.Errorf("删除 webhook 失败: %v", err))
+		return
+	}
+	util.Ok(c, gin.H{"id": sub.Id})
+}
+
+// GetWebhookDeliveries webhook 投递记录
+// @Summary      webhook 投递记录
+// @Description  需要 admin API Key；按 id 倒序返回
+// @Tags         admin
+// @Produce      json
+// @Param        id        path   int     true   "订阅ID"
+// @Param        status    query  string  false  "投递状态: pending、success、failed"
+// @Param        beforeId  query  int     false  "只返回 id 小于该值的记录，用于翻页"
+// @Param        limit     query  int     false  "返回条数，默认 50，最大 500"
+// @Success      200  {object}  util.Response
+// @Failure      404  {object}  util.Response
+// @Router       /api/v1/webplus/admin/webhooks/{id}/deliveries [get]
+func (h *Handler) GetWebhookDeliveries(c *gin.Context) {
+	targetDB, ok := adminTargetDB(c)
+	if !ok {
+		return
+	}
+	sub, ok := findWebhook(c, targetDB)
+	if !ok {
+		return
+	}
+	query := targetDB.Where("subscriptionId = ?", sub.Id)
+	switch status := util.GetParam(c, "status"); status {
+	case "":
+	case models.WebhookDeliveryPending, models.WebhookDeliverySuccess, models.WebhookDeliveryFailed:
+		query = query.Where("status = ?", status)
+	default:
+		util.Err(c, gin.H{"error": fmt.Sprintf("不支持的 status: %s", status), "code": http.StatusBadRequest})
+		return
+	}
+	if s := util.GetParam(c, "beforeId"); s != "" {
+		beforeId, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			util.Err(c, gin.H{"error": fmt.Sprintf("beforeId 必须为数字: %s", s), "code": http.StatusBadRequest})
+			return
+		}
+		query = query.Where("id < ?", beforeId)
+	}
+	limit, _ := strconv.Atoi(util.GetParam(c, "limit"))
+	if limit < 1 || limit > maxWebhookDeliveriesLimit {
+		limit = defaultWebhookDeliveriesLimit
+	}
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		util.Err(c, fmt.Errorf("查询投递记录失败: %v", err))
+		return
+	}
+	util.Ok(c, gin.H{"items": deliveries})
+}
+
+// ReplayWebhookDelivery 重新投递一条记录
+// @Summary      重新投递 webhook
+// @Description  需要 admin API Key；将投递记录重置为 pending 并立即投递，重试次数从 0 开始
+// @Tags         admin
+// @Produce      json
+// @Param        id  path  int  true  "投递记录ID"
+// @Success      200  {object}  util.Response
+// @Failure      404  {object}  util.Response
+// @Router       /api/v1/webplus/admin/webhookDeliveries/{id}/replay [post]
+func (h *Handler) ReplayWebhookDelivery(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		util.Err(c, gin.H{"error": fmt.Sprintf("id 必须为数字: %s", c.Param("id")), "code": http.StatusBadRequest})
+		return
+	}
+	targetDB, ok := adminTargetDB(c)
+	if !ok {
+		return
+	}
+	res := targetDB.Model(&models.WebhookDelivery{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":        models.WebhookDeliveryPending,
+		"attempts":      0,
+		"lastError":     "",
+		"nextAttemptAt": time.Now(),
+	})
+	if res.Error != nil {
+		util.Err(c, fmt.Errorf("重置投递记录失败: %v", res.Error))
+		return
+	}
+	if res.RowsAffected == 0 {
+		util.Err(c, gin.H{"error": fmt.Sprintf("投递记录不存在: %d", id), "code": http.StatusNotFound})
+		return
+	}
+	if webhooks != nil {
+		webhooks.notify()
+	}
+	util.Ok(c, gin.H{"id": id, "status": models.WebhookDeliveryPending})
+}
+
+// ReplayWebhook 按变更日志重新投递
+// @Summary      按变更日志重新投递 webhook
+// @Description  需要 admin API Key；为 article_change_log 中 seq 大于 since、且匹配该订阅的变更重新生成投递记录，用于接收方丢失数据后补发
+// @Tags         admin
+// @Produce      json
+// @Param        id     path   int  true   "订阅ID"
+// @Param        since  query  int  true   "从该 seq 之后开始（不含）"
+// @Param        limit  query  int  false  "最多扫描的变更数，默认 100，最大 1000"
+// @Success      200  {object}  util.Response
+// @Failure      400  {object}  util.Response
+// @Failure      404  {object}  util.Response
+// @Router       /api/v1/webplus/admin/webhooks/{id}/replay [post]
+func (h *Handler) ReplayWebhook(c *gin.Context) {
+	since, err := strconv.ParseInt(util.GetParam(c, "since"), 10, 64)
+	if err != nil || since < 0 {
+		util.Err(c, gin.H{"error": fmt.Sprintf("since 必须为非负整数: %s", util.GetParam(c, "since")), "code": http.StatusBadRequest})
+		return
+	}
+	limit, _ := strconv.Atoi(util.GetParam(c, "limit"))
+	if limit < 1 || limit > maxChangesLimit {
+		limit = defaultChangesLimit
+	}
+	targetDB, ok := adminTargetDB(c)
+	if !ok {
+		return
+	}
+	sub, ok := findWebhook(c, targetDB)
+	if !ok {
+		return
+	}
+
+	logs, err := queryArticleChanges(targetDB, nil, since, limit+1)
+	if err != nil {
+		util.Err(c, err)
+		return
+	}
+	hasMore := len(logs) > limit
+	if hasMore {
+		logs = logs[:limit]
+	}
+	nextSince := since
+	var deliveries []*models.WebhookDelivery
+	for i := range logs {
+		nextSince = logs[i].Seq
+		if !webhookMatches(*sub, &logs[i]) {
+			continue
+		}
+		delivery, err := newWebhookDelivery(*sub, &logs[i])
+		if err != nil {
+			util.Err(c, err)
+			return
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if len(deliveries) > 0 {
+		if err := targetDB.Create(&deliveries).Error; err != nil {
+			util.Err(c, fmt.Errorf("写入投递记录失败: %v", err))
+			return
+		}
+		if webhooks != nil {
+			webhooks.notify()
+		}
+	}
+	util.Ok(c, gin.H{
+		"queued":    len(deliveries),
+		"nextSince": nextSince,
+		"hasMore":   hasMore,
+	})
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"testing"
+	"time"
+	"webplus-openapi/pkg/models"
+)
+
+func TestSignWebhook(t *testing.T) {
+	// 与接收方的校验方式一致：HMAC-SHA256(secret, timestamp + "." + body)
+	got := signWebhook("s3cret", "1700000000", []byte(`{"seq":1}`))
+	if want := "cbdcfdffb94ac16f20fc7dff420aed60e8abb875b1394e892e008fdba0124439"; got != want {
+		t.Fatalf("signWebhook = %s, want %s", got, want)
+	}
+	if signWebhook("other", "1700000000", []byte(`{"seq":1}`)) == got {
+		t.Fatal("different secrets produce the same signature")
+	}
+	if signWebhook("s3cret", "1700000001", []byte(`{"seq":1}`)) == got {
+		t.Fatal("timestamp is not covered by the signature")
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	d := &webhookDispatcher{retryBase: 30 * time.Second}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{20, webhookMaxRetryDelay},
+	}
+	for _, tt := range tests {
+		if got := d.retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookMatches(t *testing.T) {
+	change := &models.ArticleChangeLog{Op: models.ArticleChangeUpsert, SiteId: "12", ColumnIds: "5,7"}
+	tests := []struct {
+		name string
+		sub  models.WebhookSubscription
+		want bool
+	}{
+		{"no filter", models.WebhookSubscription{}, true},
+		{"site", models.WebhookSubscription{SiteIds: "3, 12"}, true},
+		{"column", models.WebhookSubscription{ColumnIds: "7"}, true},
+		{"site or column", models.WebhookSubscription{SiteIds: "3", ColumnIds: "7"}, true},
+		{"out of scope", models.WebhookSubscription{SiteIds: "3", ColumnIds: "8"}, false},
+		{"op filtered", models.WebhookSubscription{Ops: "delete"}, false},
+		{"op matched", models.WebhookSubscription{Ops: "delete,upsert", SiteIds: "12"}, true},
+	}
+	for _, tt := range tests {
+		if got := webhookMatches(tt.sub, change); got != tt.want {
+			t.Errorf("%s: webhookMatches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
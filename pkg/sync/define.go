@@ -1,11 +1,15 @@
 package sync
 
-import "webplus-openapi/pkg/db"
+import (
+	"webplus-openapi/pkg/db"
+	"webplus-openapi/pkg/metrics"
+)
 
 type Config struct {
 	SourceDB *db.Config      `json:"sourceDB" yaml:"sourceDB"` // 来源库（读取）
 	TargetDB *db.Config      `json:"targetDB" yaml:"targetDB"` // 业务字典库（写入/存储状态）
 	Schedule *ScheduleConfig `json:"schedule" yaml:"schedule"`
+	Metrics  *metrics.Config `json:"metrics" yaml:"metrics"` // 指标监听或推送
 }
 
 type ScheduleConfig struct {
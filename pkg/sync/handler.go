@@ -7,6 +7,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"webplus-openapi/pkg/metrics"
 	"webplus-openapi/pkg/models"
 
 	"github.com/robfig/cron/v3"
@@ -45,7 +46,7 @@ func NewTableSyncService(sourceDB, targetDB *gorm.DB, tableName string, entityTy
 }
 
 // Sync 执行同步操作
-func (s *TableSyncService) Sync() error {
+func (s *TableSyncService) Sync() (err error) {
 	s.mu.Lock()
 	if s.running {
 		s.mu.Unlock()
@@ -60,6 +61,13 @@ func (s *TableSyncService) Sync() error {
 		s.mu.Unlock()
 	}()
 
+	// 统计变更，同步结束时记录指标
+	var added, updated, deleted int
+	startTime := time.Now()
+	defer func() {
+		metrics.ObserveTableSync(s.tableName, added, updated, deleted, time.Since(startTime), err)
+	}()
+
 	if s.sourceDB == nil {
 		return fmt.Errorf("SourceDB 未初始化")
 	}
@@ -68,7 +76,6 @@ func (s *TableSyncService) Sync() error {
 	}
 
 	zap.S().Infof("开始同步 %s 表...", s.serviceName)
-	startTime := time.Now()
 
 	// 1. 从 SourceDB 读取所有数据
 	// 创建 []*Entity 类型的切片
@@ -102,10 +109,7 @@ func (s *TableSyncService) Sync() error {
 		targetMap[id] = item
 	}
 
-	// 4. 统计变更
-	var added, updated, deleted int
-
-	// 5. 开始事务
+	// 4. 开始事务
 	tx := s.targetDB.Begin()
 	if tx.Error != nil {
 		return fmt.Errorf("开启事务失败: %w", tx.Error)
@@ -117,7 +121,7 @@ func (s *TableSyncService) Sync() error {
 		}
 	}()
 
-	// 6. 处理新增和更新
+	// 5. 处理新增和更新
 	for i := 0; i < sourceLen; i++ {
 		sourceItem := sourceSlice.Index(i).Interface()
 		sourceID := s.getIdFromEntity(sourceItem)
@@ -145,7 +149,7 @@ func (s *TableSyncService) Sync() error {
 		}
 	}
 
-	// 7. 处理删除（targetDB 中存在但 SourceDB 中不存在的）
+	// 6. 处理删除（targetDB 中存在但 SourceDB 中不存在的）
 	for id := range targetMap {
 		if err := tx.Table(s.tableName).Where("Id = ? OR id = ?", id, id).Delete(reflect.New(s.entityType).Interface()).Error; err != nil {
 			tx.Rollback()
@@ -154,7 +158,7 @@ func (s *TableSyncService) Sync() error {
 		deleted++
 	}
 
-	// 8. 提交事务
+	// 7. 提交事务
 	if err := tx.Commit().Error; err != nil {
 		return fmt.Errorf("提交事务失败: %w", err)
 	}
@@ -163,9 +167,31 @@ func (s *TableSyncService) Sync() error {
 	zap.S().Infof("%s 表同步完成 - 新增: %d, 更新: %d, 删除: %d, 耗时: %v",
 		s.serviceName, added, updated, deleted, duration)
 
+	s.saveSyncState(added, updated, deleted)
 	return nil
 }
 
+// saveSyncState 记录本次同步结果，有变更时更新 lastChangeTime，供 api 服务清空响应缓存，失败只记录日志
+func (s *TableSyncService) saveSyncState(added, updated, deleted int) {
+	now := time.Now()
+	values := map[string]interface{}{
+		"lastSyncTime": now,
+		"added":        added,
+		"updated":      updated,
+		"deleted":      deleted,
+	}
+	if added+updated+deleted > 0 {
+		values["lastChangeTime"] = now
+	}
+	state := models.SyncState{Table: s.tableName}
+	err := s.targetDB.Where(models.SyncState{Table: s.tableName}).
+		Assign(values).
+		FirstOrCreate(&state).Error
+	if err != nil {
+		zap.S().Warnf("记录 %s 同步状态失败: %v", s.serviceName, err)
+	}
+}
+
 // getIdFromEntity 从实体中获取 ID
 func (s *TableSyncService) getIdFromEntity(entity interface{}) int {
 	v := reflect.ValueOf(entity)
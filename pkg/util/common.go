@@ -1,6 +1,8 @@
 package util
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -16,15 +18,26 @@ type Response struct {
 }
 
 // Ok 成功响应
+// GET/HEAD 请求按 data 计算弱 ETag，并输出 SetLastModified 记录的 Last-Modified，客户端缓存有效时返回 304
 func Ok(c *gin.Context, data interface{}) {
 	if data == nil {
 		data = gin.H{}
 	}
 
+	payload, err := json.Marshal(data)
+	if err != nil {
+		Err(c, fmt.Errorf("序列化响应失败: %v", err))
+		return
+	}
+	modified, _ := lastModified(c)
+	if NotModified(c, WeakETag(payload), modified) {
+		return
+	}
+
 	c.JSON(http.StatusOK, Response{
 		Code:      http.StatusOK,
 		Message:   "success",
-		Data:      data,
+		Data:      json.RawMessage(payload),
 		Timestamp: time.Now().Unix(),
 	})
 }
@@ -56,3 +69,15 @@ func Err(c *gin.Context, err interface{}) {
 		Timestamp: time.Now().Unix(),
 	})
 }
+
+// HasParam 判断 Query、PostForm 或 JSON 请求体中是否传入了参数（允许为空值）
+func HasParam(c *gin.Context, key string) bool {
+	if _, ok := c.GetQuery(key); ok {
+		return true
+	}
+	if _, ok := c.GetPostForm(key); ok {
+		return true
+	}
+	_, ok := jsonParam(c, key)
+	return ok
+}
@@ -0,0 +1,87 @@
+package util
+
+import (
+	"encoding/hex"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// lastModifiedKey gin.Context 中保存结果集最后修改时间的键
+const lastModifiedKey = "webplus.lastModified"
+
+// SetLastModified 记录数据的修改时间，多次调用取最大值，Ok 据此输出 Last-Modified；
+// 只适用于单条数据，列表中的删除、排序变化不会反映到最晚修改时间上，列表只使用 ETag
+func SetLastModified(c *gin.Context, t *time.Time) {
+	if t == nil || t.IsZero() {
+		return
+	}
+	if prev, ok := c.Get(lastModifiedKey); ok {
+		if p, ok := prev.(time.Time); ok && !t.After(p) {
+			return
+		}
+	}
+	c.Set(lastModifiedKey, *t)
+}
+
+// lastModified 返回 SetLastModified 记录的时间
+func lastModified(c *gin.Context) (time.Time, bool) {
+	if v, ok := c.Get(lastModifiedKey); ok {
+		if t, ok := v.(time.Time); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// WeakETag 按响应数据计算弱 ETag
+func WeakETag(payload []byte) string {
+	h := fnv.New64a()
+	_, _ = h.Write(payload)
+	return `W/"` + hex.EncodeToString(h.Sum(nil)) + `"`
+}
+
+// NotModified 输出 ETag、Last-Modified 响应头，并按 If-None-Match（优先）或 If-Modified-Since 判断客户端缓存是否仍然有效
+// 有效时返回 304 并返回 true，调用方不需要再输出响应体；只有 GET、HEAD 请求会返回 304
+func NotModified(c *gin.Context, etag string, modified time.Time) bool {
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+	if !modified.IsZero() {
+		c.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		if etag == "" || !etagMatch(inm, etag) {
+			return false
+		}
+	} else if ims := c.GetHeader("If-Modified-Since"); ims != "" && !modified.IsZero() {
+		since, err := http.ParseTime(ims)
+		// Last-Modified 精确到秒
+		if err != nil || modified.Truncate(time.Second).After(since) {
+			return false
+		}
+	} else {
+		return false
+	}
+	c.AbortWithStatus(http.StatusNotModified)
+	return true
+}
+
+// etagMatch 按弱比较判断 If-None-Match 中是否包含 etag
+func etagMatch(header, etag string) bool {
+	target := strings.TrimPrefix(etag, "W/")
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "*" || strings.TrimPrefix(part, "W/") == target {
+			return true
+		}
+	}
+	return false
+}
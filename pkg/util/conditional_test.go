@@ -0,0 +1,108 @@
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEtagMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		etag   string
+		want   bool
+	}{
+		{`W/"abc"`, `W/"abc"`, true},
+		{`"abc"`, `W/"abc"`, true},
+		{`"x", W/"abc"`, `W/"abc"`, true},
+		{`*`, `W/"abc"`, true},
+		{`W/"abd"`, `W/"abc"`, false},
+		{`W/"abc`, `W/"abc"`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatch(tt.header, tt.etag); got != tt.want {
+			t.Errorf("etagMatch(%q, %q) = %v, want %v", tt.header, tt.etag, got, tt.want)
+		}
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	modified := time.Date(2025, 3, 1, 8, 0, 0, 500, time.UTC)
+	etag := `W/"abc"`
+	tests := []struct {
+		name     string
+		method   string
+		headers  map[string]string
+		etag     string
+		modified time.Time
+		want     bool
+	}{
+		{name: "no validators", method: http.MethodGet, etag: etag, modified: modified},
+		{name: "etag match", method: http.MethodGet, headers: map[string]string{"If-None-Match": etag}, etag: etag, want: true},
+		{name: "etag mismatch", method: http.MethodGet, headers: map[string]string{"If-None-Match": `W/"x"`}, etag: etag, want: false},
+		{
+			name:     "if-none-match wins over if-modified-since",
+			method:   http.MethodGet,
+			headers:  map[string]string{"If-None-Match": `W/"x"`, "If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat)},
+			etag:     etag,
+			modified: modified,
+			want:     false,
+		},
+		{
+			name:     "not modified since, sub-second ignored",
+			method:   http.MethodGet,
+			headers:  map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)},
+			modified: modified,
+			want:     true,
+		},
+		{
+			name:     "modified after",
+			method:   http.MethodGet,
+			headers:  map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)},
+			modified: modified,
+			want:     false,
+		},
+		{
+			name:    "if-modified-since without last-modified",
+			method:  http.MethodGet,
+			headers: map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)},
+			etag:    etag,
+			want:    false,
+		},
+		{
+			name:     "bad date",
+			method:   http.MethodGet,
+			headers:  map[string]string{"If-Modified-Since": "yesterday"},
+			modified: modified,
+			want:     false,
+		},
+		{name: "post never 304", method: http.MethodPost, headers: map[string]string{"If-None-Match": etag}, etag: etag, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(tt.method, "/", nil)
+			for k, v := range tt.headers {
+				c.Request.Header.Set(k, v)
+			}
+			got := NotModified(c, tt.etag, tt.modified)
+			if got != tt.want {
+				t.Fatalf("NotModified = %v, want %v", got, tt.want)
+			}
+			if got && c.Writer.Status() != http.StatusNotModified {
+				t.Fatalf("status = %d, want 304", c.Writer.Status())
+			}
+			if tt.etag != "" && w.Header().Get("ETag") != tt.etag {
+				t.Fatalf("ETag header = %q", w.Header().Get("ETag"))
+			}
+			if !tt.modified.IsZero() && w.Header().Get("Last-Modified") != tt.modified.Format(http.TimeFormat) {
+				t.Fatalf("Last-Modified header = %q", w.Header().Get("Last-Modified"))
+			}
+		})
+	}
+}
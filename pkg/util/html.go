@@ -0,0 +1,33 @@
+package util
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlInvisibleRe = regexp.MustCompile(`(?is)<(script|style|noscript)[^>]*>.*?</(script|style|noscript)>`)
+	htmlBreakRe     = regexp.MustCompile(`(?i)<\s*(br|/p|/div|/li|/tr|/h[1-6])\s*/?>`)
+	htmlTagRe       = regexp.MustCompile(`(?s)<[^>]*>`)
+	spaceRe         = regexp.MustCompile(`[ \t\r\f\v\x{00a0}\x{3000}]+`)
+	blankLineRe     = regexp.MustCompile(`\n\s*\n+`)
+)
+
+// HTMLToText 将站群正文 HTML 转换为纯文本：去除标签、还原实体并合并空白
+func HTMLToText(s string) string {
+	if s == "" {
+		return ""
+	}
+	s = htmlInvisibleRe.ReplaceAllString(s, "")
+	s = htmlBreakRe.ReplaceAllString(s, "\n")
+	s = htmlTagRe.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = spaceRe.ReplaceAllString(s, " ")
+	s = blankLineRe.ReplaceAllString(s, "\n")
+	lines := strings.Split(s, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
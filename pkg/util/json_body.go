@@ -0,0 +1,123 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jsonBodyKey gin.Context 中保存已解析 JSON 请求体的键
+const jsonBodyKey = "webplus.jsonBody"
+
+// maxJSONBodySize JSON 请求体的最大长度
+const maxJSONBodySize = 1 << 20
+
+// jsonBody 已解析的 JSON 请求体，err 不为空表示请求体不是合法的 JSON 对象
+type jsonBody struct {
+	values map[string]json.RawMessage
+	err    error
+}
+
+// isJSONRequest 判断请求体是否为 JSON
+func isJSONRequest(c *gin.Context) bool {
+	return c.Request != nil && c.Request.Body != nil && c.ContentType() == "application/json"
+}
+
+// parseJSONBody 解析并缓存 JSON 请求体（顶层必须是对象），读取后恢复 Request.Body 供后续再次读取
+func parseJSONBody(c *gin.Context) *jsonBody {
+	if v, ok := c.Get(jsonBodyKey); ok {
+		if b, ok := v.(*jsonBody); ok {
+			return b
+		}
+	}
+	b := &jsonBody{}
+	if isJSONRequest(c) {
+		raw, err := io.ReadAll(io.LimitReader(c.Request.Body, maxJSONBodySize+1))
+		c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+		switch {
+		case err != nil:
+			b.err = fmt.Errorf("读取请求体失败: %v", err)
+		case len(raw) > maxJSONBodySize:
+			b.err = fmt.Errorf("请求体超过 %d 字节", maxJSONBodySize)
+		case len(bytes.TrimSpace(raw)) > 0:
+			if err := json.Unmarshal(raw, &b.values); err != nil {
+				b.err = fmt.Errorf("请求体不是合法的 JSON 对象: %v", err)
+			}
+		}
+	}
+	c.Set(jsonBodyKey, b)
+	return b
+}
+
+// JSONBody 中间件：提前解析 JSON 请求体，格式错误时返回 400
+func JSONBody() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if b := parseJSONBody(c); b.err != nil {
+			Err(c, gin.H{"error": b.err.Error(), "code": http.StatusBadRequest})
+			return
+		}
+		c.Next()
+	}
+}
+
+// JSONBodyValues 返回 JSON 请求体的顶层字段，不是 JSON 请求时返回 nil
+func JSONBodyValues(c *gin.Context) map[string]json.RawMessage {
+	return parseJSONBody(c).values
+}
+
+// BindJSONParam 将 JSON 请求体中的 key 解码到 v，未传该字段时返回 false
+func BindJSONParam(c *gin.Context, key string, v interface{}) (bool, error) {
+	raw, ok := parseJSONBody(c).values[key]
+	if !ok || string(raw) == "null" {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, fmt.Errorf("%s 格式错误: %v", key, err)
+	}
+	return true, nil
+}
+
+// jsonParam 将 JSON 请求体中的 key 转换为与 Query 参数一致的字符串：数组以逗号连接，对象返回空字符串
+func jsonParam(c *gin.Context, key string) (string, bool) {
+	raw, ok := parseJSONBody(c).values[key]
+	if !ok {
+		return "", false
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return "", true
+	}
+	if arr, ok := v.([]interface{}); ok {
+		parts := make([]string, 0, len(arr))
+		for _, item := range arr {
+			if s := jsonScalarString(item); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ","), true
+	}
+	return jsonScalarString(v), true
+}
+
+// jsonScalarString 将 JSON 标量转换为字符串，null、对象和数组返回空字符串
+func jsonScalarString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		if val {
+			return "true"
+		}
+		return "false"
+	}
+	return ""
+}
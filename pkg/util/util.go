@@ -48,10 +48,14 @@ func ParseArticleTime(val string) (time.Time, bool) {
 	return time.Time{}, false
 }
 
-// GetParam 从 Query 或 PostForm 获取参数（优先 Query）
+// GetParam 从 Query、PostForm 或 JSON 请求体获取参数（优先 Query），JSON 数组以逗号连接
 func GetParam(c *gin.Context, key string) string {
 	if val := c.Query(key); val != "" {
 		return val
 	}
-	return c.PostForm(key)
+	if val := c.PostForm(key); val != "" {
+		return val
+	}
+	val, _ := jsonParam(c, key)
+	return val
 }